@@ -4,6 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
+
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/jackc/pgx/v5"
 )
 
 type SQLRow interface {
@@ -36,7 +40,66 @@ type Database interface {
 	Close() error
 	Ping() error
 	Driver() driver.Driver
-	Begin(ctx context.Context) (Transaction, error)
+	Begin(ctx context.Context, opts *TxOptions) (Transaction, error)
+}
+
+// TxOptions holds the options used to start a transaction with [Database.Begin].
+//
+// A nil *TxOptions starts a transaction with the default options of the database.
+type TxOptions struct {
+	// Isolation is the isolation level of the transaction, I.E. [sql.LevelSerializable].
+	//
+	// The default isolation level of the database is used if it is [sql.LevelDefault].
+	Isolation sql.IsolationLevel
+
+	// ReadOnly starts a read-only transaction.
+	//
+	// SQLite does not support read-only transactions, starting one returns an error.
+	ReadOnly bool
+}
+
+// SQL returns the options as [sql.TxOptions] for database/sql drivers.
+func (o *TxOptions) SQL() *sql.TxOptions {
+	if o == nil {
+		return nil
+	}
+	return &sql.TxOptions{
+		Isolation: o.Isolation,
+		ReadOnly:  o.ReadOnly,
+	}
+}
+
+// PGX returns the options as [pgx.TxOptions].
+//
+// An error is returned if the isolation level is not supported by postgres.
+func (o *TxOptions) PGX() (pgx.TxOptions, error) {
+	var opts pgx.TxOptions
+	if o == nil {
+		return opts, nil
+	}
+
+	switch o.Isolation {
+	case sql.LevelDefault:
+	case sql.LevelReadUncommitted:
+		opts.IsoLevel = pgx.ReadUncommitted
+	case sql.LevelReadCommitted:
+		opts.IsoLevel = pgx.ReadCommitted
+	case sql.LevelRepeatableRead:
+		opts.IsoLevel = pgx.RepeatableRead
+	case sql.LevelSerializable:
+		opts.IsoLevel = pgx.Serializable
+	default:
+		return opts, fmt.Errorf(
+			"isolation level %s is not supported by pgx: %w",
+			o.Isolation, query_errors.ErrNotImplemented,
+		)
+	}
+
+	if o.ReadOnly {
+		opts.AccessMode = pgx.ReadOnly
+	}
+
+	return opts, nil
 }
 
 // This interface is compatible with `*sql.Tx`.
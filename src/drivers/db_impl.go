@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
 
 	"github.com/Nigel2392/go-django-queries/src/query_errors"
 	"github.com/jackc/pgx/v5"
@@ -54,8 +55,17 @@ type dbWrapper struct {
 	*sql.DB
 }
 
-func (d *dbWrapper) Begin(ctx context.Context) (Transaction, error) {
-	tx, err := d.DB.BeginTx(ctx, nil)
+func (d *dbWrapper) Begin(ctx context.Context, opts *TxOptions) (Transaction, error) {
+	// the SQLite driver ignores the read-only option,
+	// the transaction would silently allow writes.
+	if _, ok := d.DB.Driver().(*DriverSQLite); ok && opts != nil && opts.ReadOnly {
+		return nil, fmt.Errorf(
+			"read-only transactions are not supported by SQLite: %w",
+			query_errors.ErrNotImplemented,
+		)
+	}
+
+	tx, err := d.DB.BeginTx(ctx, opts.SQL())
 	if err != nil {
 		return nil, err
 	}
@@ -115,8 +125,13 @@ func (c *connWrapper) ExecContext(ctx context.Context, query string, args ...any
 	return &pgResult{CommandTag: result}, nil
 }
 
-func (c *connWrapper) Begin(ctx context.Context) (Transaction, error) {
-	tx, err := c.conn.Begin(ctx)
+func (c *connWrapper) Begin(ctx context.Context, opts *TxOptions) (Transaction, error) {
+	txOptions, err := opts.PGX()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := c.conn.BeginTx(ctx, txOptions)
 	if err != nil {
 		return nil, err
 	}
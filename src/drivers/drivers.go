@@ -32,12 +32,15 @@ var drivers = &driverRegistry{
 
 func init() {
 	sql.Register("mariadb", DriverMariaDB{})
+	sql.Register(SQLITE_DRIVER_NAME, &DriverSQLite{
+		ConnectHook: SQLiteConnectHook,
+	})
 
 	Register("sqlite3", Driver{
 		SupportsReturning: SupportsReturningColumns,
 		Driver:            &DriverSQLite{},
 		Open: func(ctx context.Context, dsn string) (Database, error) {
-			return OpenSQL("sqlite3", dsn)
+			return OpenSQL(SQLITE_DRIVER_NAME, dsn)
 		},
 	})
 	Register("mysql", Driver{
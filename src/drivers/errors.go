@@ -0,0 +1,84 @@
+package drivers
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// SQLSTATE unique_violation
+	pgUniqueViolation = "23505"
+
+	// ER_DUP_ENTRY and ER_DUP_ENTRY_WITH_KEY_NAME
+	mysqlDupEntry            = 1062
+	mysqlDupEntryWithKeyName = 1586
+
+	// SQLSTATE serialization_failure and deadlock_detected
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+
+	// ER_LOCK_DEADLOCK and ER_LOCK_WAIT_TIMEOUT
+	mysqlLockDeadlock    = 1213
+	mysqlLockWaitTimeout = 1205
+)
+
+// IsUniqueViolation reports whether the error was caused by a unique or primary key constraint violation.
+//
+// It recognizes the errors of the SQLite, PostgreSQL (pgx) and MySQL / MariaDB drivers, wrapped errors are unwrapped.
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDupEntry ||
+			mysqlErr.Number == mysqlDupEntryWithKeyName
+	}
+
+	return false
+}
+
+// IsRetryable reports whether the error was caused by a conflict with a concurrent transaction,
+// after which the transaction can be retried.
+//
+// These are serialization failures and deadlocks in PostgreSQL (pgx), deadlocks and lock wait timeouts
+// in MySQL / MariaDB and `SQLITE_BUSY` in SQLite, wrapped errors are unwrapped.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgSerializationFailure ||
+			pgErr.Code == pgDeadlockDetected
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlLockDeadlock ||
+			mysqlErr.Number == mysqlLockWaitTimeout
+	}
+
+	return false
+}
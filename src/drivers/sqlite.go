@@ -0,0 +1,116 @@
+package drivers
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// SQLITE_DRIVER_NAME is the name the SQLite driver with the functions
+// of [SQLiteConnectHook] is registered under in the database/sql package.
+//
+// It is used when opening a SQLite database with [Open].
+const SQLITE_DRIVER_NAME = "sqlite3_queries"
+
+// SQLiteConnectHook registers the functions which SQLite does not provide
+// by default on a new connection, such as the `REGEXP` function used by the `regex` and `iregex` lookups.
+//
+// Databases opened with [Open] already use this hook, it can be used as the
+// ConnectHook of a custom [sqlite3.SQLiteDriver] to support the same lookups.
+func SQLiteConnectHook(conn *sqlite3.SQLiteConn) error {
+	if err := conn.RegisterFunc("regexp", sqliteRegexp, true); err != nil {
+		return fmt.Errorf("failed to register REGEXP function: %w", err)
+	}
+	return nil
+}
+
+// SQLITE_REGEXP_CACHE_SIZE is the maximum number of compiled patterns
+// which are cached by the `REGEXP` function of [SQLiteConnectHook].
+const SQLITE_REGEXP_CACHE_SIZE = 128
+
+// sqliteRegexpCache holds the most recently used patterns, the patterns
+// might come from user input so the number of cached patterns is bounded.
+var sqliteRegexpCache = &regexpCache{
+	size:    SQLITE_REGEXP_CACHE_SIZE,
+	entries: make(map[string]*list.Element),
+	order:   list.New(),
+}
+
+// regexpCache is a least recently used cache of compiled regular expressions.
+type regexpCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// compile returns the compiled pattern from the cache, or compiles and caches it.
+func (c *regexpCache) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := c.get(pattern); ok {
+		return re, nil
+	}
+
+	// compiled without holding the lock, another
+	// goroutine might have cached the pattern already.
+	var re, err = regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*regexp.Regexp), nil
+	}
+
+	c.entries[pattern] = c.order.PushFront(re)
+	for c.order.Len() > c.size {
+		var elem = c.order.Back()
+		c.order.Remove(elem)
+		delete(c.entries, elem.Value.(*regexp.Regexp).String())
+	}
+
+	return re, nil
+}
+
+func (c *regexpCache) get(pattern string) (*regexp.Regexp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var elem, ok = c.entries[pattern]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return elem.Value.(*regexp.Regexp), true
+}
+
+// sqliteRegexp implements `value REGEXP pattern`, which SQLite calls as `regexp(pattern, value)`.
+//
+// NULL values never match.
+func sqliteRegexp(pattern string, value any) (bool, error) {
+	var str string
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		str = fmt.Sprint(v)
+	}
+
+	var re, err = sqliteRegexpCache.compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	return re.MatchString(str), nil
+}
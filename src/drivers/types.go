@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -12,6 +13,7 @@ type (
 	Bytes  []byte
 	Float  float64
 	Time   = time.Time
+	JSON   json.RawMessage
 )
 
 //
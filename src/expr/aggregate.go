@@ -0,0 +1,114 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+)
+
+// FilteredAggregate represents an aggregate function which only
+// takes the rows matching a condition into account, I.E. `fn FILTER (WHERE ...)`.
+//
+// It is created with [Function.Filter] and can be used in [QuerySet.Annotate] or [QuerySet.Aggregate] like any other expression.
+type FilteredAggregate struct {
+	fn        *Function
+	condition Expression
+	emulated  Expression
+	used      bool
+}
+
+// Filter limits the rows which are aggregated by the function to the rows matching the conditions, I.E.:
+//
+//	COUNT("ID").Filter(Q("Published", true))
+//
+// Multiple conditions are combined with AND.
+//
+// This compiles to `COUNT(...) FILTER (WHERE ...)` for PostgreSQL and SQLite.
+// MySQL and MariaDB do not support the FILTER clause, the argument of the function is
+// wrapped in a [CaseExpression] instead, I.E. `SUM(CASE WHEN ... THEN ... END)`.
+//
+// The function must be an aggregate function with exactly one argument, like [SUM], [COUNT], [AVG], [MAX] or [MIN].
+func (e *Function) Filter(conditions ...Expression) *FilteredAggregate {
+	if len(conditions) == 0 {
+		panic("no conditions provided for aggregate filter")
+	}
+
+	if len(e.inner) != 1 {
+		panic("aggregate filter requires a function with exactly one argument")
+	}
+
+	var condition Expression
+	if len(conditions) == 1 {
+		condition = conditions[0]
+	} else {
+		condition = And(conditions...)
+	}
+
+	return &FilteredAggregate{
+		fn:        e,
+		condition: condition,
+	}
+}
+
+func (f *FilteredAggregate) FieldName() string {
+	return f.fn.FieldName()
+}
+
+func (f *FilteredAggregate) Clone() Expression {
+	var emulated Expression
+	if f.emulated != nil {
+		emulated = f.emulated.Clone()
+	}
+
+	return &FilteredAggregate{
+		fn:        f.fn.Clone().(*Function),
+		condition: f.condition.Clone(),
+		emulated:  emulated,
+		used:      f.used,
+	}
+}
+
+func (f *FilteredAggregate) Resolve(inf *ExpressionInfo) Expression {
+	if inf.Model == nil || f.used {
+		return f
+	}
+
+	var nF = f.Clone().(*FilteredAggregate)
+	nF.used = true
+
+	switch inf.Driver.(type) {
+	case *drivers.DriverMySQL, *drivers.DriverMariaDB:
+		// `fn(CASE WHEN condition THEN value END)`, rows which do not
+		// match the condition are NULL and thus ignored by the aggregate.
+		var fn = nF.fn.Clone().(*Function)
+		fn.inner[0] = Case(&when{
+			lhs:  nF.condition,
+			then: fn.inner[0],
+		})
+		nF.emulated = fn.Resolve(inf)
+	default:
+		nF.fn = nF.fn.Resolve(inf).(*Function)
+		nF.condition = nF.condition.Resolve(inf)
+	}
+
+	return nF
+}
+
+func (f *FilteredAggregate) SQL(sb *strings.Builder) []any {
+	if !f.used {
+		panic("FilteredAggregate was not resolved, cannot generate SQL")
+	}
+
+	if f.emulated != nil {
+		return f.emulated.SQL(sb)
+	}
+
+	var args = make([]any, 0)
+	args = append(args, f.fn.SQL(sb)...)
+
+	sb.WriteString(" FILTER (WHERE ")
+	args = append(args, f.condition.SQL(sb)...)
+	sb.WriteString(")")
+
+	return args
+}
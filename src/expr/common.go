@@ -61,6 +61,71 @@ func (e *field) Resolve(inf *ExpressionInfo) Expression {
 	return nE
 }
 
+func (e *field) ProvideLookup(inf *ExpressionInfo, name string) (Lookup, bool) {
+	if e.field == nil || e.field.Lookups == nil {
+		return nil, false
+	}
+	return e.field.Lookups.ProvideLookup(inf, name)
+}
+
+func (e *field) ProvideTransform(inf *ExpressionInfo, name string) (LookupTransform, bool) {
+	if e.field == nil || e.field.Lookups == nil {
+		return nil, false
+	}
+	return e.field.Lookups.ProvideTransform(inf, name)
+}
+
+// outerRef is a field of the outer query referenced from inside of a subquery.
+// See [OuterRef] for more information.
+type outerRef struct {
+	fieldName string
+	field     *ResolvedField
+	used      bool
+}
+
+// OuterRef references a field of the outer query from inside of a subquery.
+//
+// It is resolved against the model of the outer query when the subquery is compiled,
+// and panics if it is used outside of a subquery.
+// It can be used like so:
+//
+//	Subquery(comments.Filter("Post", OuterRef("ID")).OrderBy("-Created").Limit(1))
+//
+// A subquery which selects from the same table as the outer query is compiled with an alias
+// for its table, so that the columns of the outer query can still be referenced.
+func OuterRef(fld string) Expression {
+	return &outerRef{fieldName: fld}
+}
+
+func (e *outerRef) SQL(sb *strings.Builder) []any {
+	sb.WriteString(e.field.SQLText)
+	return e.field.SQLArgs
+}
+
+func (e *outerRef) Clone() Expression {
+	return &outerRef{fieldName: e.fieldName, field: e.field, used: e.used}
+}
+
+func (e *outerRef) Resolve(inf *ExpressionInfo) Expression {
+	if inf.Model == nil || e.used {
+		return e
+	}
+
+	if inf.Outer == nil {
+		panic(fmt.Errorf("OuterRef(%q) can only be used inside of a subquery", e.fieldName))
+	}
+
+	// the outer column is always qualified with its table name,
+	// also when the outer query is an UPDATE statement.
+	var outer = *inf.Outer
+	outer.ForUpdate = false
+
+	var nE = e.Clone().(*outerRef)
+	nE.used = true
+	nE.field = outer.ResolveExpressionField(nE.fieldName)
+	return nE
+}
+
 // value is a type that implements the Expression interface.
 // See [Value] for more information.
 type value struct {
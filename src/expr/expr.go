@@ -100,6 +100,17 @@ type Lookup interface {
 	Resolve(inf *ExpressionInfo, lhsResolved ResolvedExpression, args []any) LookupExpression
 }
 
+// LookupProvider is implemented by fields and expressions which provide their own lookups and transforms,
+// such as JSON fields.
+//
+// The left-hand side of a lookup is asked for each part of the lookup,
+// the lookups and transforms it provides take precedence over the registered ones.
+// A field can implement it to provide the lookups for the column of the field.
+type LookupProvider interface {
+	ProvideLookup(inf *ExpressionInfo, name string) (Lookup, bool)
+	ProvideTransform(inf *ExpressionInfo, name string) (LookupTransform, bool)
+}
+
 type TableColumn struct {
 	// The table or alias to use in the join condition
 	// If this is set, the FieldColumn must be specified
@@ -199,6 +210,17 @@ type NamedExpression interface {
 	FieldName() string
 }
 
+// SubqueryExpression is implemented by expressions which write a subquery in parentheses, I.E. `(SELECT ...)`.
+//
+// Lookups which already wrap their value in parentheses, like the `in` lookup, write the subquery
+// with SubquerySQL - `IN ((SELECT ...))` is treated as a scalar subquery by MySQL and PostgreSQL.
+type SubqueryExpression interface {
+	Expression
+
+	// SubquerySQL writes the subquery without the surrounding parentheses.
+	SubquerySQL(sb *strings.Builder) []any
+}
+
 var logicalOps = map[string]LogicalOp{
 	// Equality comparison operators
 	"=":  EQ,
@@ -186,4 +186,75 @@ func init() {
 		}
 		return "", nil, fmt.Errorf("unsupported driver for NOW: %T", d)
 	})
+
+	// Window functions
+	//
+	// These are supported by sqlite (3.25+), mysql (8.0+), mariadb (10.2+) and postgres
+	// and can only be used inside of a [Window] expression.
+	RegisterFunc("ROW_NUMBER", windowFunc("ROW_NUMBER"))
+	RegisterFunc("RANK", windowFunc("RANK"))
+	RegisterFunc("DENSE_RANK", windowFunc("DENSE_RANK"))
+	RegisterFunc("NTILE", func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+		if len(value) != 0 {
+			return "", []any{}, fmt.Errorf("NTILE lookup does not accept any values")
+		}
+		if len(funcParams) != 1 {
+			return "", []any{}, fmt.Errorf("NTILE lookup requires exactly one function parameter (buckets)")
+		}
+		var buckets, ok = funcParams[0].(int)
+		if !ok || buckets <= 0 {
+			return "", []any{}, fmt.Errorf("NTILE lookup requires a positive integer amount of buckets, got %v", funcParams[0])
+		}
+		return fmt.Sprintf("NTILE(%d)", buckets), []any{}, nil
+	})
+	RegisterFunc("LAG", windowOffsetFunc("LAG"))
+	RegisterFunc("LEAD", windowOffsetFunc("LEAD"))
+	RegisterFunc("FIRST_VALUE", func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+		if len(value) != 1 {
+			return "", []any{}, fmt.Errorf("FIRST_VALUE lookup requires exactly one value")
+		}
+		var sb strings.Builder
+		args = value[0].SQL(&sb)
+		return fmt.Sprintf("FIRST_VALUE(%s)", sb.String()), args, nil
+	})
+}
+
+func windowFunc(name string) func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+	return func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+		if len(value) != 0 {
+			return "", []any{}, fmt.Errorf("%s lookup does not accept any values", name)
+		}
+		return fmt.Sprintf("%s()", name), []any{}, nil
+	}
+}
+
+func windowOffsetFunc(name string) func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+	return func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+		if len(value) != 1 {
+			return "", []any{}, fmt.Errorf("%s lookup requires exactly one value", name)
+		}
+		if len(funcParams) == 0 || len(funcParams) > 2 {
+			return "", []any{}, fmt.Errorf("%s lookup requires an offset and an optional default value", name)
+		}
+
+		var sb strings.Builder
+		args = value[0].SQL(&sb)
+
+		var offset, ok = funcParams[0].(int)
+		if !ok {
+			return "", []any{}, fmt.Errorf("%s lookup requires an integer offset, got %T", name, funcParams[0])
+		}
+		fmt.Fprintf(&sb, ", %d", offset)
+
+		if len(funcParams) == 2 {
+			var dflt, ok = funcParams[1].(Expression)
+			if !ok {
+				return "", []any{}, fmt.Errorf("%s lookup requires the default value to be an Expression, got %T", name, funcParams[1])
+			}
+			sb.WriteString(", ")
+			args = append(args, dflt.SQL(&sb)...)
+		}
+
+		return fmt.Sprintf("%s(%s)", name, sb.String()), args, nil
+	}
 }
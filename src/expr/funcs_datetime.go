@@ -0,0 +1,186 @@
+package expr
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+)
+
+// DatePart is a part of a date or time value, used by [Extract] and [Trunc].
+type DatePart string
+
+const (
+	DatePartYear   DatePart = "year"
+	DatePartMonth  DatePart = "month"
+	DatePartWeek   DatePart = "week"
+	DatePartDay    DatePart = "day"
+	DatePartHour   DatePart = "hour"
+	DatePartMinute DatePart = "minute"
+	DatePartSecond DatePart = "second"
+
+	// DatePartWeekDay is the day of the week, ranging from 1 (Sunday) to 7 (Saturday).
+	//
+	// It can only be extracted, not truncated to.
+	DatePartWeekDay DatePart = "week_day"
+)
+
+// The formats are passed the SQL of the expression as the first argument,
+// which can be referenced multiple times with `%[1]s`.
+var (
+	sqliteExtractFormats = map[DatePart]string{
+		DatePartYear:    "CAST(STRFTIME('%%Y', %[1]s) AS INTEGER)",
+		DatePartMonth:   "CAST(STRFTIME('%%m', %[1]s) AS INTEGER)",
+		DatePartWeek:    "((CAST(STRFTIME('%%j', DATE(%[1]s, '-3 days', 'weekday 4')) AS INTEGER) - 1) / 7 + 1)",
+		DatePartDay:     "CAST(STRFTIME('%%d', %[1]s) AS INTEGER)",
+		DatePartWeekDay: "(CAST(STRFTIME('%%w', %[1]s) AS INTEGER) + 1)",
+		DatePartHour:    "CAST(STRFTIME('%%H', %[1]s) AS INTEGER)",
+		DatePartMinute:  "CAST(STRFTIME('%%M', %[1]s) AS INTEGER)",
+		DatePartSecond:  "CAST(STRFTIME('%%S', %[1]s) AS INTEGER)",
+	}
+	sqliteTruncFormats = map[DatePart]string{
+		DatePartYear:   "STRFTIME('%%Y-01-01 00:00:00', %[1]s)",
+		DatePartMonth:  "STRFTIME('%%Y-%%m-01 00:00:00', %[1]s)",
+		DatePartWeek:   "STRFTIME('%%Y-%%m-%%d 00:00:00', %[1]s, '-6 days', 'weekday 1')",
+		DatePartDay:    "STRFTIME('%%Y-%%m-%%d 00:00:00', %[1]s)",
+		DatePartHour:   "STRFTIME('%%Y-%%m-%%d %%H:00:00', %[1]s)",
+		DatePartMinute: "STRFTIME('%%Y-%%m-%%d %%H:%%M:00', %[1]s)",
+		DatePartSecond: "STRFTIME('%%Y-%%m-%%d %%H:%%M:%%S', %[1]s)",
+	}
+
+	postgresExtractFormats = map[DatePart]string{
+		DatePartYear:    "CAST(EXTRACT(YEAR FROM %[1]s) AS INTEGER)",
+		DatePartMonth:   "CAST(EXTRACT(MONTH FROM %[1]s) AS INTEGER)",
+		DatePartWeek:    "CAST(EXTRACT(WEEK FROM %[1]s) AS INTEGER)",
+		DatePartDay:     "CAST(EXTRACT(DAY FROM %[1]s) AS INTEGER)",
+		DatePartWeekDay: "(CAST(EXTRACT(DOW FROM %[1]s) AS INTEGER) + 1)",
+		DatePartHour:    "CAST(EXTRACT(HOUR FROM %[1]s) AS INTEGER)",
+		DatePartMinute:  "CAST(EXTRACT(MINUTE FROM %[1]s) AS INTEGER)",
+		DatePartSecond:  "CAST(FLOOR(EXTRACT(SECOND FROM %[1]s)) AS INTEGER)",
+	}
+	postgresTruncFormats = map[DatePart]string{
+		DatePartYear:   "DATE_TRUNC('year', %[1]s)",
+		DatePartMonth:  "DATE_TRUNC('month', %[1]s)",
+		DatePartWeek:   "DATE_TRUNC('week', %[1]s)",
+		DatePartDay:    "DATE_TRUNC('day', %[1]s)",
+		DatePartHour:   "DATE_TRUNC('hour', %[1]s)",
+		DatePartMinute: "DATE_TRUNC('minute', %[1]s)",
+		DatePartSecond: "DATE_TRUNC('second', %[1]s)",
+	}
+
+	mysqlExtractFormats = map[DatePart]string{
+		DatePartYear:    "YEAR(%[1]s)",
+		DatePartMonth:   "MONTH(%[1]s)",
+		DatePartWeek:    "WEEK(%[1]s, 3)",
+		DatePartDay:     "DAYOFMONTH(%[1]s)",
+		DatePartWeekDay: "DAYOFWEEK(%[1]s)",
+		DatePartHour:    "HOUR(%[1]s)",
+		DatePartMinute:  "MINUTE(%[1]s)",
+		DatePartSecond:  "SECOND(%[1]s)",
+	}
+	mysqlTruncFormats = map[DatePart]string{
+		DatePartYear:   "CAST(DATE_FORMAT(%[1]s, '%%Y-01-01 00:00:00') AS DATETIME)",
+		DatePartMonth:  "CAST(DATE_FORMAT(%[1]s, '%%Y-%%m-01 00:00:00') AS DATETIME)",
+		DatePartWeek:   "CAST(DATE_FORMAT(DATE_SUB(%[1]s, INTERVAL WEEKDAY(%[1]s) DAY), '%%Y-%%m-%%d 00:00:00') AS DATETIME)",
+		DatePartDay:    "CAST(DATE_FORMAT(%[1]s, '%%Y-%%m-%%d 00:00:00') AS DATETIME)",
+		DatePartHour:   "CAST(DATE_FORMAT(%[1]s, '%%Y-%%m-%%d %%H:00:00') AS DATETIME)",
+		DatePartMinute: "CAST(DATE_FORMAT(%[1]s, '%%Y-%%m-%%d %%H:%%i:00') AS DATETIME)",
+		DatePartSecond: "CAST(DATE_FORMAT(%[1]s, '%%Y-%%m-%%d %%H:%%i:%%s') AS DATETIME)",
+	}
+)
+
+func init() {
+	RegisterFunc("EXTRACT", dateFunc("EXTRACT", sqliteExtractFormats), &drivers.DriverSQLite{})
+	RegisterFunc("EXTRACT", dateFunc("EXTRACT", postgresExtractFormats), &drivers.DriverPostgres{})
+	RegisterFunc("EXTRACT", dateFunc("EXTRACT", mysqlExtractFormats), &drivers.DriverMySQL{}, &drivers.DriverMariaDB{})
+
+	RegisterFunc("TRUNC", dateFunc("TRUNC", sqliteTruncFormats), &drivers.DriverSQLite{})
+	RegisterFunc("TRUNC", dateFunc("TRUNC", postgresTruncFormats), &drivers.DriverPostgres{})
+	RegisterFunc("TRUNC", dateFunc("TRUNC", mysqlTruncFormats), &drivers.DriverMySQL{}, &drivers.DriverMariaDB{})
+
+	RegisterFunc("DATE", func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+		if len(value) != 1 {
+			return "", []any{}, fmt.Errorf("DATE lookup requires exactly one value")
+		}
+		var sb strings.Builder
+		args = value[0].SQL(&sb)
+		switch d.(type) {
+		case *drivers.DriverMySQL, *drivers.DriverMariaDB, *drivers.DriverSQLite:
+			return fmt.Sprintf("DATE(%s)", sb.String()), args, nil
+		case *drivers.DriverPostgres:
+			return fmt.Sprintf("CAST(%s AS DATE)", sb.String()), args, nil
+		}
+		return "", nil, fmt.Errorf("unsupported driver for DATE: %T", d)
+	})
+}
+
+func dateFunc(name string, formats map[DatePart]string) func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+	return func(d driver.Driver, value []Expression, funcParams []any) (sql string, args []any, err error) {
+		if len(value) != 1 {
+			return "", []any{}, fmt.Errorf("%s lookup requires exactly one value", name)
+		}
+		if len(funcParams) != 1 {
+			return "", []any{}, fmt.Errorf("%s lookup requires exactly one function parameter (date part)", name)
+		}
+
+		var part, ok = funcParams[0].(DatePart)
+		if !ok {
+			return "", []any{}, fmt.Errorf("%s lookup requires a DatePart, got %T", name, funcParams[0])
+		}
+
+		format, ok := formats[part]
+		if !ok {
+			return "", []any{}, fmt.Errorf("%s lookup does not support date part %q for driver %T", name, part, d)
+		}
+
+		var sb strings.Builder
+		var inner = value[0].SQL(&sb)
+
+		// the expression can be referenced multiple times by the format
+		args = make([]any, 0, len(inner))
+		for range strings.Count(format, "%[1]s") {
+			args = append(args, inner...)
+		}
+
+		return fmt.Sprintf(format, sb.String()), args, nil
+	}
+}
+
+// Extract returns the given part of a date or time expression as an integer, I.E.:
+//
+//	Extract("CreatedAt", DatePartYear)
+//
+// The week is the ISO-8601 week number, the week day ranges from 1 (Sunday) to 7 (Saturday).
+//
+// It is also available as a lookup transform, I.E. `Filter("CreatedAt__year__gte", 2024)`.
+func Extract(expr any, part DatePart) *Function {
+	if _, ok := sqliteExtractFormats[part]; !ok {
+		panic(fmt.Errorf("Extract: unsupported date part %q", part))
+	}
+	return newFunc("EXTRACT", []any{part}, expr)
+}
+
+// Trunc truncates a date or time expression to the start of the given part, I.E.:
+//
+//	Trunc("CreatedAt", DatePartMonth)
+//
+// truncates the expression to the first day of the month at midnight.
+// Weeks start on monday, [DatePartWeekDay] is not supported.
+//
+// SQLite does not have a datetime type, the result is a `YYYY-MM-DD HH:MM:SS` string.
+//
+// It is also available as a lookup transform, I.E. `Filter("CreatedAt__trunc_month", "2024-01-01 00:00:00")`.
+func Trunc(expr any, part DatePart) *Function {
+	if _, ok := sqliteTruncFormats[part]; !ok {
+		panic(fmt.Errorf("Trunc: unsupported date part %q", part))
+	}
+	return newFunc("TRUNC", []any{part}, expr)
+}
+
+// DATE returns the date part of a date or time expression.
+//
+// It is also available as a lookup transform, I.E. `Filter("CreatedAt__date", "2024-01-01")`.
+func DATE(expr any) *Function {
+	return newFunc("DATE", []any{}, expr)
+}
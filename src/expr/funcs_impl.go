@@ -162,3 +162,68 @@ func LENGTH(expr any) *Function {
 func NOW() *Function {
 	return newFunc("NOW", []any{}, nil)
 }
+
+// ROW_NUMBER returns the number of the current row within its partition.
+//
+// It can only be used inside of a [Window] expression.
+func ROW_NUMBER() *Function {
+	return newFunc("ROW_NUMBER", []any{})
+}
+
+// RANK returns the rank of the current row within its partition, with gaps.
+//
+// It can only be used inside of a [Window] expression.
+func RANK() *Function {
+	return newFunc("RANK", []any{})
+}
+
+// DENSE_RANK returns the rank of the current row within its partition, without gaps.
+//
+// It can only be used inside of a [Window] expression.
+func DENSE_RANK() *Function {
+	return newFunc("DENSE_RANK", []any{})
+}
+
+// NTILE divides the rows of the partition into n buckets and returns the bucket number of the current row.
+//
+// It can only be used inside of a [Window] expression.
+func NTILE(n int) *Function {
+	return newFunc("NTILE", []any{n})
+}
+
+// LAG returns the value of expr for the row offset rows before the current row.
+//
+// An offset of 0 or less defaults to 1, the default value is returned if no such row exists.
+//
+// It can only be used inside of a [Window] expression.
+func LAG(expr any, offset int, dflt ...any) *Function {
+	return newFunc("LAG", windowOffsetParams(offset, dflt), expr)
+}
+
+// LEAD returns the value of expr for the row offset rows after the current row.
+//
+// An offset of 0 or less defaults to 1, the default value is returned if no such row exists.
+//
+// It can only be used inside of a [Window] expression.
+func LEAD(expr any, offset int, dflt ...any) *Function {
+	return newFunc("LEAD", windowOffsetParams(offset, dflt), expr)
+}
+
+// FIRST_VALUE returns the value of expr for the first row of the window frame.
+//
+// It can only be used inside of a [Window] expression.
+func FIRST_VALUE(expr any) *Function {
+	return newFunc("FIRST_VALUE", []any{}, expr)
+}
+
+func windowOffsetParams(offset int, dflt []any) []any {
+	if offset <= 0 {
+		offset = 1
+	}
+
+	var params = []any{offset}
+	if len(dflt) > 0 {
+		params = append(params, Value(dflt[0]))
+	}
+	return params
+}
@@ -140,6 +140,23 @@ type ExpressionInfo struct {
 
 	// Annotations is a map of queryset annotations (fields).
 	Annotations *orderedmap.OrderedMap[string, attrs.Field]
+
+	// Outer is the expression info of the outer query if the expression is part of a subquery.
+	//
+	// It is used to resolve [OuterRef] expressions, it is nil for the top- level query.
+	Outer *ExpressionInfo
+
+	// TableAlias is the alias of the base table of the query.
+	//
+	// It is set when a subquery selects from the same table as the outer query,
+	// so that the outer table can still be referenced with [OuterRef].
+	TableAlias string
+
+	// ResolveColumn resolves references to columns which do not belong to a field of the model,
+	// I.E. `alias.Field` for the columns of a subquery which is joined to the query.
+	//
+	// It is optional, if it returns false the reference is resolved as a field of the model.
+	ResolveColumn func(field string) (*TableColumn, bool)
 }
 
 func (inf *ExpressionLookupInfo) FormatLogicalOpRHS(op LogicalOp, rhs string, values ...any) (string, []any) {
@@ -185,6 +202,9 @@ type ResolvedField struct {
 	SQLArgs           []any
 	AllowedTransforms []string
 	AllowedLookups    []string
+
+	// Lookups is set if the field implements [LookupProvider].
+	Lookups LookupProvider
 }
 
 func newResolvedField(fieldPath, sqlText string, field attrs.FieldDefinition, args []any) *ResolvedField {
@@ -196,6 +216,7 @@ func newResolvedField(fieldPath, sqlText string, field attrs.FieldDefinition, ar
 		transforms = v.AllowedTransforms()
 		lookups = v.AllowedLookups()
 	}
+	var provider, _ = field.(LookupProvider)
 	return &ResolvedField{
 		FieldPath:         fieldPath,
 		Field:             field.Name(),
@@ -203,10 +224,23 @@ func newResolvedField(fieldPath, sqlText string, field attrs.FieldDefinition, ar
 		SQLArgs:           args,
 		AllowedTransforms: transforms,
 		AllowedLookups:    lookups,
+		Lookups:           provider,
 	}
 }
 
 func (inf *ExpressionInfo) ResolveExpressionField(field string) *ResolvedField {
+	if inf.ResolveColumn != nil {
+		if col, ok := inf.ResolveColumn(field); ok {
+			var sql, args = inf.FormatField(col)
+			return &ResolvedField{
+				FieldPath: field,
+				Field:     field,
+				SQLText:   sql,
+				SQLArgs:   args,
+			}
+		}
+	}
+
 	var current, _, f, chain, aliases, isRelated, err = internal.WalkFields(inf.Model, field, inf.AliasGen)
 	if err != nil {
 		if fld, ok := inf.Annotations.Get(field); ok {
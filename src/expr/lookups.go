@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/Nigel2392/go-django-queries/src/drivers"
 	"github.com/Nigel2392/go-django/src/core/errs"
 )
 
@@ -38,6 +39,19 @@ func init() {
 	RegisterLookup(patternLookup(LOOKUP_IENDSWITH, "%%%s"))
 	RegisterLookup(patternLookup(LOOKUP_ENDSWITH, "%%%s"))
 
+	RegisterLookup(formatLookup(LOOKUP_REGEX, "%s ~ %s", nil, &drivers.DriverPostgres{}))
+	RegisterLookup(formatLookup(LOOKUP_IREGEX, "%s ~* %s", nil, &drivers.DriverPostgres{}))
+	RegisterLookup(formatLookup(LOOKUP_REGEX, "REGEXP_LIKE(%s, %s, 'c')", nil, &drivers.DriverMySQL{}))
+	RegisterLookup(formatLookup(LOOKUP_IREGEX, "REGEXP_LIKE(%s, %s, 'i')", nil, &drivers.DriverMySQL{}))
+	RegisterLookup(formatLookup(LOOKUP_REGEX, "%s REGEXP CONCAT('(?-i)', %s)", nil, &drivers.DriverMariaDB{}))
+	RegisterLookup(formatLookup(LOOKUP_IREGEX, "%s REGEXP CONCAT('(?i)', %s)", nil, &drivers.DriverMariaDB{}))
+	RegisterLookup(formatLookup(LOOKUP_REGEX, "%s REGEXP %s", nil, &drivers.DriverSQLite{}))
+	RegisterLookup(formatLookup(LOOKUP_IREGEX, "%s REGEXP '(?i)' || %s", nil, &drivers.DriverSQLite{}))
+
+	RegisterLookup(formatLookup(LOOKUP_SEARCH, "to_tsvector(%s) @@ plainto_tsquery(%s)", nil, &drivers.DriverPostgres{}))
+	RegisterLookup(formatLookup(LOOKUP_SEARCH, "MATCH (%s) AGAINST (%s IN BOOLEAN MODE)", booleanModeTerms, &drivers.DriverMySQL{}, &drivers.DriverMariaDB{}))
+	RegisterLookup(formatLookup(LOOKUP_SEARCH, "%s MATCH %s", fts5Terms, &drivers.DriverSQLite{}))
+
 	RegisterLookup(&InLookup{
 		BaseLookup: BaseLookup{
 			Identifier: LOOKUP_IN,
@@ -96,6 +110,30 @@ func init() {
 			return MAX(lhsResolved).Resolve(inf), nil
 		},
 	})
+	RegisterTransforms(&BaseTransform{
+		Identifier: "date",
+		Transform: func(inf *ExpressionInfo, lhsResolved ResolvedExpression) (ResolvedExpression, error) {
+			return DATE(lhsResolved).Resolve(inf), nil
+		},
+	})
+
+	// Date and time transforms, I.E. `CreatedAt__year` and `CreatedAt__trunc_month`.
+	for part := range sqliteExtractFormats {
+		RegisterTransforms(&BaseTransform{
+			Identifier: string(part),
+			Transform: func(inf *ExpressionInfo, lhsResolved ResolvedExpression) (ResolvedExpression, error) {
+				return Extract(lhsResolved, part).Resolve(inf), nil
+			},
+		})
+	}
+	for part := range sqliteTruncFormats {
+		RegisterTransforms(&BaseTransform{
+			Identifier: "trunc_" + string(part),
+			Transform: func(inf *ExpressionInfo, lhsResolved ResolvedExpression) (ResolvedExpression, error) {
+				return Trunc(lhsResolved, part).Resolve(inf), nil
+			},
+		})
+	}
 }
 
 const (
@@ -133,14 +171,46 @@ const (
 	LOOKUP_IN          = "in"
 	LOOKUP_ISNULL      = "isnull"
 	LOOKUP_RANGE       = "range"
+	LOOKUP_REGEX       = "regex"
+	LOOKUP_IREGEX      = "iregex"
+	LOOKUP_SEARCH      = "search"
 
 	DEFAULT_LOOKUP = LOOKUP_EXACT
-
-	// NYI
-	// LOOKUP_REGEX  = "regex"
-	// LOOKUP_IREGEX = "iregex"
 )
 
+// booleanModeTerms requires each word of the search query in a MySQL boolean mode search, I.E. `+"go" +"language"`.
+//
+// The words are quoted so that they are not interpreted as boolean mode operators,
+// boolean mode has no escape for double quotes so these are removed.
+func booleanModeTerms(v any) any {
+	var query, ok = v.(string)
+	if !ok {
+		return v
+	}
+
+	var terms = strings.Fields(strings.ReplaceAll(query, `"`, " "))
+	for i, term := range terms {
+		terms[i] = fmt.Sprintf(`+"%s"`, term)
+	}
+	return strings.Join(terms, " ")
+}
+
+// fts5Terms quotes each word of a search query as an FTS5 string,
+// the words are then matched like `plainto_tsquery` on postgres: all of them have to be present,
+// and characters which have a meaning in the FTS5 query syntax are matched literally.
+func fts5Terms(v any) any {
+	var query, ok = v.(string)
+	if !ok {
+		return v
+	}
+
+	var terms = strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = fmt.Sprintf(`"%s"`, strings.ReplaceAll(term, `"`, `""`))
+	}
+	return strings.Join(terms, " ")
+}
+
 var lookupsRegistry = &lookupRegistry{
 	lookupsLocal:  make(map[reflect.Type]map[string]Lookup),
 	lookupsGlobal: make(map[string]Lookup),
@@ -175,20 +245,7 @@ func GetLookup(inf *ExpressionInfo, lookupName string, lhs any, args []any) (fun
 		return nil, fmt.Errorf("expression info cannot be nil")
 	}
 
-	var (
-		transforms  []string
-		lookupParts = strings.Split(lookupName, "__")
-	)
-
-	if len(lookupParts) > 1 {
-		transforms = lookupParts[:len(lookupParts)-1]
-		lookupName = lookupParts[len(lookupParts)-1]
-	}
-
-	if lookupName != "" && !lookupsRegistry.HasLookup(lookupName, inf.Driver) {
-		transforms = append(transforms, lookupName)
-		lookupName = DEFAULT_LOOKUP
-	}
-
-	return lookupsRegistry.Lookup(inf, transforms, lookupName, lhs, args)
+	// The lookup is split into its transforms and the lookup itself by the registry,
+	// the left-hand side can provide its own lookups and transforms, see [LookupProvider].
+	return lookupsRegistry.Lookup(inf, strings.Split(lookupName, "__"), lhs, args)
 }
@@ -226,7 +226,7 @@ func (l *InLookup) NormalizeArgs(inf *ExpressionInfo, values []any) ([]any, erro
 		if len(values) > 1 {
 			return nil, fmt.Errorf("lookup %s cannot be used with an expression and additional values", l.Identifier)
 		}
-		return []any{v}, nil
+		return []any{v.Resolve(inf)}, nil
 	}
 
 	return flattenList(values), nil
@@ -242,7 +242,11 @@ func (l *InLookup) Resolve(inf *ExpressionInfo, resolvedExpression ResolvedExpre
 		sb.WriteString(" IN (")
 
 		switch v := values[0].(type) {
-		case Expression: // handle expression case (maybe subquery)
+		case SubqueryExpression:
+			args = append(
+				args, v.SubquerySQL(sb)...,
+			)
+		case Expression: // handle expression case
 			args = append(
 				args, v.SQL(sb)...,
 			)
@@ -299,6 +303,54 @@ func (l *RangeLookup) Resolve(inf *ExpressionInfo, resolvedExpression ResolvedEx
 	}
 }
 
+// FormatLookup is a lookup which writes the left-hand side and the right-hand side
+// of the lookup into a format string, I.E. `%s ~ %s` or `MATCH (%s) AGAINST (%s)`.
+//
+// The left-hand side is always the first format argument, the right-hand side the second.
+// The right-hand side is either a placeholder for the value or the SQL of an expression.
+type FormatLookup struct {
+	BaseLookup
+	Format string
+}
+
+func (l *FormatLookup) Arity() (min, max int) {
+	return 1, 1
+}
+
+func (l *FormatLookup) NormalizeArgs(inf *ExpressionInfo, values []any) ([]any, error) {
+	if len(values) != 1 {
+		return nil, fmt.Errorf("lookup %s requires exactly one value", l.Identifier)
+	}
+
+	switch v := values[0].(type) {
+	case Expression:
+		return []any{v.Resolve(inf)}, nil
+	}
+
+	return l.BaseLookup.NormalizeArgs(inf, values)
+}
+
+func (l *FormatLookup) Resolve(inf *ExpressionInfo, resolvedExpression ResolvedExpression, values []any) func(sb *strings.Builder) []any {
+	return func(sb *strings.Builder) []any {
+		var lhsExpr strings.Builder
+		var args = resolvedExpression.SQL(&lhsExpr)
+
+		var rhs string
+		switch arg := values[0].(type) {
+		case Expression:
+			var inner strings.Builder
+			args = append(args, arg.SQL(&inner)...)
+			rhs = inner.String()
+		default:
+			rhs = inf.Placeholder
+			args = append(args, arg)
+		}
+
+		sb.WriteString(fmt.Sprintf(l.Format, lhsExpr.String(), rhs))
+		return args
+	}
+}
+
 func flattenList(values []any) []any {
 	var inList = make([]any, 0, len(values))
 	for _, v := range values {
@@ -346,6 +398,17 @@ func patternLookup(lookupName string, pattern string, allowedDrivers ...driver.D
 	}
 }
 
+func formatLookup(lookupName string, format string, normalize func(any) any, allowedDrivers ...driver.Driver) Lookup {
+	return &FormatLookup{
+		BaseLookup: BaseLookup{
+			Identifier:     lookupName,
+			AllowedDrivers: allowedDrivers,
+			Normalize:      normalize,
+		},
+		Format: format,
+	}
+}
+
 func normalizeDefinerArg(v any) any {
 	if definer, ok := v.(attrs.Definer); ok {
 		var fieldDefs = definer.FieldDefs()
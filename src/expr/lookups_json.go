@@ -0,0 +1,513 @@
+package expr
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+)
+
+const (
+	LOOKUP_HAS_KEY      = "has_key"
+	LOOKUP_HAS_KEYS     = "has_keys"
+	LOOKUP_CONTAINED_BY = "contained_by"
+)
+
+// JSONLookups provides the key transforms and the lookups of JSON columns.
+//
+// Fields which store JSON can implement [LookupProvider] by delegating to it.
+//
+// Any transform is a key (or an index, if the key is a number) of the JSON value,
+// I.E. `Filter("Meta__author__name", "x")` compares the value of `author.name`.
+// The value of a key is extracted as a scalar, so that the registered lookups like `exact`, `gt` or `icontains` can be used on it.
+//
+// It provides the following lookups, these compare JSON values:
+//
+// - has_key: the JSON object has the given key.
+// - has_keys: the JSON object has all of the given keys.
+// - contains: the JSON value contains the given value, I.E. `{"a": 1, "b": 2}` contains `{"a": 1}`.
+// - contained_by: the JSON value is contained by the given value.
+var JSONLookups LookupProvider = &jsonLookupProvider{
+	lookups: map[string]Lookup{
+		LOOKUP_HAS_KEY: &BaseLookup{
+			Identifier:  LOOKUP_HAS_KEY,
+			ArgMin:      1,
+			ArgMax:      1,
+			ResolveFunc: resolveJSONHasKeys,
+		},
+		LOOKUP_HAS_KEYS: &BaseLookup{
+			Identifier:  LOOKUP_HAS_KEYS,
+			ArgMin:      1,
+			ArgMax:      -1,
+			ResolveFunc: resolveJSONHasKeys,
+		},
+		LOOKUP_CONTAINS: &BaseLookup{
+			Identifier:  LOOKUP_CONTAINS,
+			ArgMin:      1,
+			ArgMax:      1,
+			ResolveFunc: resolveJSONContains(false),
+		},
+		LOOKUP_CONTAINED_BY: &BaseLookup{
+			Identifier:  LOOKUP_CONTAINED_BY,
+			ArgMin:      1,
+			ArgMax:      1,
+			ResolveFunc: resolveJSONContains(true),
+		},
+	},
+}
+
+type jsonLookupProvider struct {
+	lookups map[string]Lookup
+}
+
+func (p *jsonLookupProvider) ProvideLookup(inf *ExpressionInfo, name string) (Lookup, bool) {
+	var lookup, ok = p.lookups[name]
+	return lookup, ok
+}
+
+func (p *jsonLookupProvider) ProvideTransform(inf *ExpressionInfo, name string) (LookupTransform, bool) {
+	return &BaseTransform{
+		Identifier: name,
+		Transform: func(inf *ExpressionInfo, lhsResolved ResolvedExpression) (ResolvedExpression, error) {
+			return newJSONKeyTransform(inf, lhsResolved, name), nil
+		},
+	}, true
+}
+
+// jsonKeyTransform is the value of a key of a JSON expression, I.E. `Meta__author__name`.
+//
+// Its SQL is the scalar value of the key, the JSON lookups use [jsonKeyTransform.jsonSQL] instead.
+type jsonKeyTransform struct {
+	driver      driver.Driver
+	placeholder string
+	lhs         ResolvedExpression
+	keys        []string
+}
+
+func newJSONKeyTransform(inf *ExpressionInfo, lhs ResolvedExpression, key string) *jsonKeyTransform {
+	if t, ok := lhs.(*jsonKeyTransform); ok {
+		return &jsonKeyTransform{
+			driver:      inf.Driver,
+			placeholder: inf.Placeholder,
+			lhs:         t.lhs,
+			keys:        append(slices.Clone(t.keys), key),
+		}
+	}
+
+	return &jsonKeyTransform{
+		driver:      inf.Driver,
+		placeholder: inf.Placeholder,
+		lhs:         lhs,
+		keys:        []string{key},
+	}
+}
+
+func (e *jsonKeyTransform) ProvideLookup(inf *ExpressionInfo, name string) (Lookup, bool) {
+	return JSONLookups.ProvideLookup(inf, name)
+}
+
+func (e *jsonKeyTransform) ProvideTransform(inf *ExpressionInfo, name string) (LookupTransform, bool) {
+	return JSONLookups.ProvideTransform(inf, name)
+}
+
+func (e *jsonKeyTransform) SQL(sb *strings.Builder) []any {
+	return e.writeSQL(sb, true)
+}
+
+// jsonSQL writes the value of the key as JSON, instead of as a scalar value.
+func (e *jsonKeyTransform) jsonSQL(sb *strings.Builder) []any {
+	return e.writeSQL(sb, false)
+}
+
+func (e *jsonKeyTransform) writeSQL(sb *strings.Builder, scalar bool) []any {
+	var lhs strings.Builder
+	var args = e.lhs.SQL(&lhs)
+
+	switch e.driver.(type) {
+	case *drivers.DriverPostgres:
+		// `(lhs -> 'author' ->> 'name')`, numeric keys are array indexes
+		sb.WriteString("(")
+		sb.WriteString(lhs.String())
+		for i, key := range e.keys {
+			if scalar && i == len(e.keys)-1 {
+				sb.WriteString(" ->> ")
+			} else {
+				sb.WriteString(" -> ")
+			}
+			sb.WriteString(e.placeholder)
+			if idx, err := strconv.Atoi(key); err == nil {
+				sb.WriteString("::INT")
+				args = append(args, idx)
+			} else {
+				sb.WriteString("::TEXT")
+				args = append(args, key)
+			}
+		}
+		sb.WriteString(")")
+
+	case *drivers.DriverMySQL, *drivers.DriverMariaDB:
+		if scalar {
+			sb.WriteString("JSON_UNQUOTE(")
+		}
+		sb.WriteString("JSON_EXTRACT(")
+		sb.WriteString(lhs.String())
+		sb.WriteString(", ")
+		sb.WriteString(e.placeholder)
+		sb.WriteString(")")
+		if scalar {
+			sb.WriteString(")")
+		}
+		args = append(args, jsonPath(e.keys...))
+
+	case *drivers.DriverSQLite:
+		// json_extract returns SQL values for scalars, the -> operator always returns JSON.
+		if scalar {
+			sb.WriteString("json_extract(")
+			sb.WriteString(lhs.String())
+			sb.WriteString(", ")
+		} else {
+			sb.WriteString("(")
+			sb.WriteString(lhs.String())
+			sb.WriteString(" -> ")
+		}
+		sb.WriteString(e.placeholder)
+		sb.WriteString(")")
+		args = append(args, jsonPath(e.keys...))
+
+	default:
+		panic(fmt.Errorf("JSON key transforms are not supported for driver %T", e.driver))
+	}
+
+	return args
+}
+
+// jsonPath returns the JSON path for the given keys as used by MySQL and SQLite, I.E. `$."author"."name"`.
+func jsonPath(keys ...string) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, key := range keys {
+		if _, err := strconv.Atoi(key); err == nil {
+			sb.WriteString("[")
+			sb.WriteString(key)
+			sb.WriteString("]")
+			continue
+		}
+		sb.WriteString(`."`)
+		sb.WriteString(strings.ReplaceAll(key, `"`, `\"`))
+		sb.WriteString(`"`)
+	}
+	return sb.String()
+}
+
+// writeJSONLHS writes the left-hand side of a JSON lookup as JSON.
+func writeJSONLHS(sb *strings.Builder, lhs ResolvedExpression) []any {
+	if t, ok := lhs.(*jsonKeyTransform); ok {
+		return t.jsonSQL(sb)
+	}
+	return lhs.SQL(sb)
+}
+
+func resolveJSONHasKeys(inf *ExpressionInfo, lhsResolved ResolvedExpression, values []any) LookupExpression {
+	var keys = make([]string, 0, len(values))
+	for _, v := range flattenList(values) {
+		keys = append(keys, fmt.Sprint(v))
+	}
+
+	return func(sb *strings.Builder) []any {
+		var lhs strings.Builder
+		var lhsArgs = writeJSONLHS(&lhs, lhsResolved)
+		var args = make([]any, 0, len(keys)*(len(lhsArgs)+1))
+
+		switch inf.Driver.(type) {
+		case *drivers.DriverMySQL, *drivers.DriverMariaDB:
+			sb.WriteString("JSON_CONTAINS_PATH(")
+			sb.WriteString(lhs.String())
+			sb.WriteString(", 'all'")
+			args = append(args, lhsArgs...)
+			for _, key := range keys {
+				sb.WriteString(", ")
+				sb.WriteString(inf.Placeholder)
+				args = append(args, jsonPath(key))
+			}
+			sb.WriteString(")")
+			return args
+		}
+
+		sb.WriteString("(")
+		for i, key := range keys {
+			if i > 0 {
+				sb.WriteString(" AND ")
+			}
+
+			args = append(args, lhsArgs...)
+			switch inf.Driver.(type) {
+			case *drivers.DriverPostgres:
+				// a key with a JSON null value is not SQL NULL
+				sb.WriteString("(")
+				sb.WriteString(lhs.String())
+				sb.WriteString(" -> ")
+				sb.WriteString(inf.Placeholder)
+				sb.WriteString("::TEXT) IS NOT NULL")
+				args = append(args, key)
+			case *drivers.DriverSQLite:
+				sb.WriteString("json_type(")
+				sb.WriteString(lhs.String())
+				sb.WriteString(", ")
+				sb.WriteString(inf.Placeholder)
+				sb.WriteString(") IS NOT NULL")
+				args = append(args, jsonPath(key))
+			default:
+				panic(fmt.Errorf("JSON lookups are not supported for driver %T", inf.Driver))
+			}
+		}
+		sb.WriteString(")")
+		return args
+	}
+}
+
+func resolveJSONContains(containedBy bool) func(inf *ExpressionInfo, lhsResolved ResolvedExpression, values []any) LookupExpression {
+	return func(inf *ExpressionInfo, lhsResolved ResolvedExpression, values []any) LookupExpression {
+		var data, err = json.Marshal(values[0])
+		if err != nil {
+			panic(fmt.Errorf("failed to marshal JSON value for lookup: %w", err))
+		}
+
+		return func(sb *strings.Builder) []any {
+			var lhs strings.Builder
+			var lhsArgs = writeJSONLHS(&lhs, lhsResolved)
+
+			switch inf.Driver.(type) {
+			case *drivers.DriverPostgres:
+				var op = "@>"
+				if containedBy {
+					op = "<@"
+				}
+				sb.WriteString(lhs.String())
+				sb.WriteString(" ")
+				sb.WriteString(op)
+				sb.WriteString(" ")
+				sb.WriteString(inf.Placeholder)
+				sb.WriteString("::JSONB")
+				return append(lhsArgs, string(data))
+
+			case *drivers.DriverMySQL, *drivers.DriverMariaDB:
+				sb.WriteString("JSON_CONTAINS(")
+				if containedBy {
+					sb.WriteString(inf.Placeholder)
+					sb.WriteString(", ")
+					sb.WriteString(lhs.String())
+					sb.WriteString(")")
+					return append([]any{string(data)}, lhsArgs...)
+				}
+				sb.WriteString(lhs.String())
+				sb.WriteString(", ")
+				sb.WriteString(inf.Placeholder)
+				sb.WriteString(")")
+				return append(lhsArgs, string(data))
+
+			case *drivers.DriverSQLite:
+				var value any
+				if err := json.Unmarshal(data, &value); err != nil {
+					panic(fmt.Errorf("failed to unmarshal JSON value for lookup: %w", err))
+				}
+
+				var w = &sqliteJSONContains{
+					sb:          sb,
+					inf:         inf,
+					lhs:         lhs.String(),
+					lhsArgs:     lhsArgs,
+					containedBy: containedBy,
+				}
+				w.write(sqliteJSONPath{literal: "$"}, value)
+				return w.args
+			}
+
+			panic(fmt.Errorf("JSON lookups are not supported for driver %T", inf.Driver))
+		}
+	}
+}
+
+// sqliteJSONPath is a JSON path in SQLite, either a literal path
+// or a path relative to an SQL expression, I.E. the fullkey of a json_each row.
+type sqliteJSONPath struct {
+	expr    string
+	literal string
+}
+
+func (p sqliteJSONPath) key(key string) sqliteJSONPath {
+	var path = jsonPath(key)
+	return sqliteJSONPath{
+		expr:    p.expr,
+		literal: p.literal + strings.TrimPrefix(path, "$"),
+	}
+}
+
+// sqliteJSONContains emulates `lhs @> value` and `lhs <@ value` for SQLite, which has no JSON containment operators.
+//
+// Objects contain the keys of the value with contained values,
+// arrays contain a matching element for each element of the value, regardless of the position.
+//
+// The SQL is generated from the structure of the value, for `lhs <@ value` every key or element
+// of the left-hand side must be contained by a key or element of the value.
+type sqliteJSONContains struct {
+	sb          *strings.Builder
+	inf         *ExpressionInfo
+	lhs         string
+	lhsArgs     []any
+	args        []any
+	depth       int
+	containedBy bool
+}
+
+// writePath writes a function call on the left-hand side at the given path, I.E. `json_type(lhs, '$."a"')`.
+func (w *sqliteJSONContains) writePath(fn string, path sqliteJSONPath) {
+	w.sb.WriteString(fn)
+	w.sb.WriteString("(")
+	w.sb.WriteString(w.lhs)
+	w.sb.WriteString(", ")
+	w.args = append(w.args, w.lhsArgs...)
+	switch {
+	case path.expr == "":
+		w.sb.WriteString(w.inf.Placeholder)
+		w.args = append(w.args, path.literal)
+	case path.literal == "":
+		w.sb.WriteString(path.expr)
+	default:
+		w.sb.WriteString(path.expr)
+		w.sb.WriteString(" || ")
+		w.sb.WriteString(w.inf.Placeholder)
+		w.args = append(w.args, path.literal)
+	}
+	w.sb.WriteString(")")
+}
+
+func (w *sqliteJSONContains) write(path sqliteJSONPath, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		if w.containedBy {
+			w.writeContainedBy(path, "object", func(alias string) {
+				for i, key := range slices.Sorted(maps.Keys(v)) {
+					if i > 0 {
+						w.sb.WriteString(" OR ")
+					}
+					w.sb.WriteString("(")
+					w.sb.WriteString(alias)
+					w.sb.WriteString(".key = ")
+					w.sb.WriteString(w.inf.Placeholder)
+					w.sb.WriteString(" AND ")
+					w.args = append(w.args, key)
+					w.write(sqliteJSONPath{expr: alias + ".fullkey"}, v[key])
+					w.sb.WriteString(")")
+				}
+			}, len(v))
+			return
+		}
+
+		if len(v) == 0 {
+			w.writePath("json_type", path)
+			w.sb.WriteString(" = 'object'")
+			return
+		}
+
+		w.sb.WriteString("(")
+		for i, key := range slices.Sorted(maps.Keys(v)) {
+			if i > 0 {
+				w.sb.WriteString(" AND ")
+			}
+			w.write(path.key(key), v[key])
+		}
+		w.sb.WriteString(")")
+
+	case []any:
+		if w.containedBy {
+			w.writeContainedBy(path, "array", func(alias string) {
+				for i, elem := range v {
+					if i > 0 {
+						w.sb.WriteString(" OR ")
+					}
+					w.write(sqliteJSONPath{expr: alias + ".fullkey"}, elem)
+				}
+			}, len(v))
+			return
+		}
+
+		w.sb.WriteString("(")
+		w.writePath("json_type", path)
+		w.sb.WriteString(" = 'array'")
+
+		var alias = fmt.Sprintf("json_elem_%d", w.depth)
+		for _, elem := range v {
+			w.sb.WriteString(" AND EXISTS (SELECT 1 FROM ")
+			w.writePath("json_each", path)
+			w.sb.WriteString(" AS ")
+			w.sb.WriteString(alias)
+			w.sb.WriteString(" WHERE ")
+			w.depth++
+			w.write(sqliteJSONPath{expr: alias + ".fullkey"}, elem)
+			w.depth--
+			w.sb.WriteString(")")
+		}
+		w.sb.WriteString(")")
+
+	case nil:
+		w.writePath("json_type", path)
+		w.sb.WriteString(" = 'null'")
+
+	case bool:
+		w.writePath("json_type", path)
+		if v {
+			w.sb.WriteString(" = 'true'")
+		} else {
+			w.sb.WriteString(" = 'false'")
+		}
+
+	case string:
+		w.writeScalar(path, "'text'", v)
+
+	default:
+		w.writeScalar(path, "'integer', 'real'", v)
+	}
+}
+
+// writeScalar compares the type and the value at the given path,
+// `IS` is used so that the comparison is never NULL.
+func (w *sqliteJSONContains) writeScalar(path sqliteJSONPath, types string, value any) {
+	w.sb.WriteString("(")
+	w.writePath("json_type", path)
+	w.sb.WriteString(" IN (")
+	w.sb.WriteString(types)
+	w.sb.WriteString(") AND ")
+	w.writePath("json_extract", path)
+	w.sb.WriteString(" IS ")
+	w.sb.WriteString(w.inf.Placeholder)
+	w.sb.WriteString(")")
+	w.args = append(w.args, value)
+}
+
+// writeContainedBy writes the `lhs <@ value` check for an object or array value,
+// writeMatch writes the condition for a key or element of the left-hand side to be contained by the value.
+func (w *sqliteJSONContains) writeContainedBy(path sqliteJSONPath, typ string, writeMatch func(alias string), n int) {
+	var alias = fmt.Sprintf("json_elem_%d", w.depth)
+	w.sb.WriteString("(")
+	w.writePath("json_type", path)
+	w.sb.WriteString(" = '")
+	w.sb.WriteString(typ)
+	w.sb.WriteString("' AND NOT EXISTS (SELECT 1 FROM ")
+	w.writePath("json_each", path)
+	w.sb.WriteString(" AS ")
+	w.sb.WriteString(alias)
+	w.sb.WriteString(" WHERE NOT (")
+	if n == 0 {
+		w.sb.WriteString("0")
+	} else {
+		w.depth++
+		writeMatch(alias)
+		w.depth--
+	}
+	w.sb.WriteString(")))")
+}
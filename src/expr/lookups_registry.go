@@ -117,31 +117,29 @@ func (r *lookupRegistry) HasTransform(transformName string, driver driver.Driver
 	return ok
 }
 
-func (r *lookupRegistry) Lookup(inf *ExpressionInfo, transforms []string, lookupName string, lhs any, args []any) (func(sb *strings.Builder) []any, error) {
-	var lookup, ok = retrieveFromMap(r.lookupsLocal, r.lookupsGlobal, lookupName, inf.Driver)
-	if !ok || lookup == nil {
-		return nil, fmt.Errorf(
-			"no lookup %q found for driver %T: %w",
-			lookupName, inf.Driver, ErrLookupNotFound,
-		)
-	}
-
-	var min, max = lookup.Arity()
-	if len(args) < min || (max >= 0 && len(args) > max) {
-		return nil, fmt.Errorf(
-			"lookup %s requires between %d and %d arguments, got %d: %w",
-			lookup.Name(), min, max, len(args), ErrLookupArgsInvalid,
-		)
+func (r *lookupRegistry) lookup(inf *ExpressionInfo, lookupName string, lhs ResolvedExpression) (Lookup, bool) {
+	if provider, ok := lhs.(LookupProvider); ok {
+		if lookup, ok := provider.ProvideLookup(inf, lookupName); ok {
+			return lookup, true
+		}
 	}
+	return retrieveFromMap(r.lookupsLocal, r.lookupsGlobal, lookupName, inf.Driver)
+}
 
-	var normalizedArgs, err = lookup.NormalizeArgs(inf, args)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"error normalizing args for lookup %s: %w",
-			lookup.Name(), err,
-		)
+func (r *lookupRegistry) transform(inf *ExpressionInfo, transformName string, lhs ResolvedExpression) (LookupTransform, bool) {
+	if provider, ok := lhs.(LookupProvider); ok {
+		if transform, ok := provider.ProvideTransform(inf, transformName); ok {
+			return transform, true
+		}
 	}
+	return retrieveFromMap(r.transformsLocal, r.transformsGlobal, transformName, inf.Driver)
+}
 
+// Lookup resolves the lookup for the given parts of the lookup string, I.E. `lower__startswith`.
+//
+// All parts but the last are transforms, the last part is the lookup.
+// If the last part is not a lookup it is treated as a transform and the default lookup is used.
+func (r *lookupRegistry) Lookup(inf *ExpressionInfo, lookupParts []string, lhs any, args []any) (func(sb *strings.Builder) []any, error) {
 	var lhsExpr ResolvedExpression
 	switch lhs := lhs.(type) {
 	case string:
@@ -152,16 +150,22 @@ func (r *lookupRegistry) Lookup(inf *ExpressionInfo, transforms []string, lookup
 		return nil, fmt.Errorf("unsupported type for lhs: %T", lhs)
 	}
 
+	var (
+		transforms = lookupParts[:len(lookupParts)-1]
+		lookupName = lookupParts[len(lookupParts)-1]
+		err        error
+	)
+
 	//var (
 	//	allowedTransformsMap = make(map[string]struct{})
 	//	allowedLookupsMap    = make(map[string]struct{})
 	//	transformsMap        = make(map[string]LookupTransform)
 	//)
 
-	for _, transformName := range transforms {
-		var transform, ok = retrieveFromMap(r.transformsLocal, r.transformsGlobal, transformName, inf.Driver)
+	var resolveTransform = func(transformName string) error {
+		var transform, ok = r.transform(inf, transformName, lhsExpr)
 		if !ok || transform == nil {
-			return nil, fmt.Errorf(
+			return fmt.Errorf(
 				"no transform %q found for driver %T: %w",
 				transformName, inf.Driver, ErrTransformNotFound,
 			)
@@ -178,11 +182,51 @@ func (r *lookupRegistry) Lookup(inf *ExpressionInfo, transforms []string, lookup
 		// transformsMap[transformName] = transform
 		lhsExpr, err = transform.Resolve(inf, lhsExpr)
 		if err != nil {
-			return nil, fmt.Errorf(
+			return fmt.Errorf(
 				"error resolving transform %q for lookup %q: %w",
-				transformName, lookup.Name(), err,
+				transformName, lookupName, err,
 			)
 		}
+		return nil
+	}
+
+	for _, transformName := range transforms {
+		if err = resolveTransform(transformName); err != nil {
+			return nil, err
+		}
+	}
+
+	var lookup, ok = r.lookup(inf, lookupName, lhsExpr)
+	if !ok && lookupName != "" {
+		// the last part is a transform, use the default lookup
+		if err = resolveTransform(lookupName); err != nil {
+			return nil, err
+		}
+		lookupName = DEFAULT_LOOKUP
+		lookup, ok = r.lookup(inf, lookupName, lhsExpr)
+	}
+
+	if !ok || lookup == nil {
+		return nil, fmt.Errorf(
+			"no lookup %q found for driver %T: %w",
+			lookupName, inf.Driver, ErrLookupNotFound,
+		)
+	}
+
+	var min, max = lookup.Arity()
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return nil, fmt.Errorf(
+			"lookup %s requires between %d and %d arguments, got %d: %w",
+			lookup.Name(), min, max, len(args), ErrLookupArgsInvalid,
+		)
+	}
+
+	normalizedArgs, err := lookup.NormalizeArgs(inf, args)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error normalizing args for lookup %s: %w",
+			lookup.Name(), err,
+		)
 	}
 
 	var expr = lookup.Resolve(inf, lhsExpr, normalizedArgs)
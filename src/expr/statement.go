@@ -74,9 +74,12 @@ func (s *ExpressionStatement) Resolve(inf *ExpressionInfo) *ExpressionStatement
 			if t, ok := tables[rTyp]; ok {
 				s.info.Tables[i] = t
 			} else {
-				var defs = inf.Model.FieldDefs()
-				s.info.Tables[i] = defs.TableName()
-				tables[rTyp] = defs.TableName()
+				var tableName = inf.TableAlias
+				if tableName == "" {
+					tableName = inf.Model.FieldDefs().TableName()
+				}
+				s.info.Tables[i] = tableName
+				tables[rTyp] = tableName
 			}
 			continue
 		}
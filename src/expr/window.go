@@ -0,0 +1,252 @@
+package expr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FrameType is the type of frame used in a window function.
+//
+// It is used to define the unit of the frame, either rows or a range of values.
+type FrameType string
+
+const (
+	FrameRows  FrameType = "ROWS"
+	FrameRange FrameType = "RANGE"
+)
+
+// FrameBound is the start or end of a window function frame.
+//
+// Use [Preceding] and [Following] to create a bound with an offset.
+type FrameBound string
+
+const (
+	UnboundedPreceding FrameBound = "UNBOUNDED PRECEDING"
+	UnboundedFollowing FrameBound = "UNBOUNDED FOLLOWING"
+	CurrentRow         FrameBound = "CURRENT ROW"
+)
+
+// Preceding returns a frame bound for n rows or values before the current row.
+func Preceding(n int) FrameBound {
+	return FrameBound(fmt.Sprintf("%d PRECEDING", n))
+}
+
+// Following returns a frame bound for n rows or values after the current row.
+func Following(n int) FrameBound {
+	return FrameBound(fmt.Sprintf("%d FOLLOWING", n))
+}
+
+type windowFrame struct {
+	typ   FrameType
+	start FrameBound
+	end   FrameBound
+}
+
+type windowOrder struct {
+	expr Expression
+	desc bool
+}
+
+// WindowOption is used to configure the OVER clause of a [WindowExpression].
+//
+// See [PartitionBy], [OrderBy] and [Frame].
+type WindowOption func(w *WindowExpression)
+
+// PartitionBy adds a PARTITION BY clause to the window.
+//
+// Strings are treated as field names, expressions are used as- is.
+func PartitionBy(exprs ...any) WindowOption {
+	return func(w *WindowExpression) {
+		w.partitionBy = append(
+			w.partitionBy,
+			expressionFromInterface[Expression](exprs, false)...,
+		)
+	}
+}
+
+// OrderBy adds an ORDER BY clause to the window.
+//
+// Strings are treated as field names and can be prefixed with a minus sign (`-`)
+// to indicate descending order, expressions are always ordered ascending.
+func OrderBy(fields ...any) WindowOption {
+	return func(w *WindowExpression) {
+		for _, f := range fields {
+			switch v := f.(type) {
+			case string:
+				var desc = strings.HasPrefix(v, "-")
+				w.orderBy = append(w.orderBy, windowOrder{
+					expr: Field(strings.TrimPrefix(v, "-")),
+					desc: desc,
+				})
+			case Expression:
+				w.orderBy = append(w.orderBy, windowOrder{
+					expr: v,
+				})
+			default:
+				panic(fmt.Errorf("unsupported type %T for window order by, must be string or Expression", f))
+			}
+		}
+	}
+}
+
+// Frame adds a frame clause to the window, I.E.
+//
+//	Frame(FrameRows, UnboundedPreceding, CurrentRow)
+//
+// will result in `ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW`.
+func Frame(typ FrameType, start, end FrameBound) WindowOption {
+	return func(w *WindowExpression) {
+		w.frame = &windowFrame{
+			typ:   typ,
+			start: start,
+			end:   end,
+		}
+	}
+}
+
+// WindowExpression represents a window function call, I.E. `fn OVER (...)`.
+//
+// It can be used in [QuerySet.Annotate] or [QuerySet.Select] like any other expression.
+type WindowExpression struct {
+	fn          Expression
+	partitionBy []Expression
+	orderBy     []windowOrder
+	frame       *windowFrame
+	used        bool
+}
+
+// Window creates a new window expression for the given function.
+//
+// Any function can be used, both aggregate functions like [SUM] and [COUNT],
+// and window-only functions like [ROW_NUMBER], [RANK] and [LAG].
+//
+// It can be used like so:
+//
+//	Window(RANK(), PartitionBy("Category"), OrderBy("-Price"))
+//	Window(SUM("Amount"), OrderBy("Date"), Frame(FrameRows, UnboundedPreceding, CurrentRow))
+func Window(fn Expression, opts ...WindowOption) *WindowExpression {
+	if fn == nil {
+		panic("window function cannot be nil")
+	}
+
+	var w = &WindowExpression{
+		fn: fn,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+func (w *WindowExpression) FieldName() string {
+	if namer, ok := w.fn.(NamedExpression); ok {
+		return namer.FieldName()
+	}
+	return ""
+}
+
+func (w *WindowExpression) Clone() Expression {
+	var partitionBy = slices.Clone(w.partitionBy)
+	for i := range partitionBy {
+		partitionBy[i] = partitionBy[i].Clone()
+	}
+
+	var orderBy = slices.Clone(w.orderBy)
+	for i := range orderBy {
+		orderBy[i].expr = orderBy[i].expr.Clone()
+	}
+
+	var frame *windowFrame
+	if w.frame != nil {
+		var f = *w.frame
+		frame = &f
+	}
+
+	return &WindowExpression{
+		fn:          w.fn.Clone(),
+		partitionBy: partitionBy,
+		orderBy:     orderBy,
+		frame:       frame,
+		used:        w.used,
+	}
+}
+
+func (w *WindowExpression) Resolve(inf *ExpressionInfo) Expression {
+	if inf.Model == nil || w.used {
+		return w
+	}
+
+	var nW = w.Clone().(*WindowExpression)
+	nW.used = true
+	nW.fn = nW.fn.Resolve(inf)
+
+	for i, e := range nW.partitionBy {
+		nW.partitionBy[i] = e.Resolve(inf)
+	}
+
+	for i, ord := range nW.orderBy {
+		nW.orderBy[i].expr = ord.expr.Resolve(inf)
+	}
+
+	return nW
+}
+
+func (w *WindowExpression) SQL(sb *strings.Builder) []any {
+	if !w.used {
+		panic("WindowExpression was not resolved, cannot generate SQL")
+	}
+
+	var args = make([]any, 0)
+	args = append(args, w.fn.SQL(sb)...)
+
+	sb.WriteString(" OVER (")
+
+	var needsSpace bool
+	if len(w.partitionBy) > 0 {
+		sb.WriteString("PARTITION BY ")
+		for i, e := range w.partitionBy {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, e.SQL(sb)...)
+		}
+		needsSpace = true
+	}
+
+	if len(w.orderBy) > 0 {
+		if needsSpace {
+			sb.WriteString(" ")
+		}
+		sb.WriteString("ORDER BY ")
+		for i, ord := range w.orderBy {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, ord.expr.SQL(sb)...)
+			if ord.desc {
+				sb.WriteString(" DESC")
+			} else {
+				sb.WriteString(" ASC")
+			}
+		}
+		needsSpace = true
+	}
+
+	if w.frame != nil {
+		if needsSpace {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(string(w.frame.typ))
+		sb.WriteString(" BETWEEN ")
+		sb.WriteString(string(w.frame.start))
+		sb.WriteString(" AND ")
+		sb.WriteString(string(w.frame.end))
+	}
+
+	sb.WriteString(")")
+
+	return args
+}
@@ -0,0 +1,127 @@
+package fields
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+	"github.com/Nigel2392/go-django-queries/src/expr"
+	"github.com/Nigel2392/go-django-queries/src/migrator"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ attrs.Field            = (*JSONField[any])(nil)
+	_ expr.LookupProvider    = (*JSONField[any])(nil)
+	_ migrator.CanColumnType = (*JSONField[any])(nil)
+)
+
+// JSONField is a field which stores its value as JSON in the database.
+//
+// The value is marshalled when saving the model and unmarshalled into a new T when scanning it,
+// the struct field of the model must be of type T.
+//
+// The column is created as `JSONB` on postgres, `JSON` on mysql and mariadb and `TEXT` on sqlite.
+//
+// Keys of the JSON value can be used as lookup transforms, I.E. `Filter("Meta__author__name", "x")`,
+// see [expr.JSONLookups] for the lookups which are available.
+type JSONField[T any] struct {
+	*attrs.FieldDef
+}
+
+func NewJSONField[T any](forModel attrs.Definer, name string, conf *attrs.FieldConfig) *JSONField[T] {
+	if conf == nil {
+		conf = &attrs.FieldConfig{}
+	}
+
+	var f = &JSONField[T]{
+		FieldDef: attrs.NewField(forModel, name, conf),
+	}
+
+	var typ = reflect.TypeOf((*T)(nil)).Elem()
+	if f.FieldDef.Type() != typ {
+		panic(errors.Errorf(
+			"NewJSONField: field %q of %T is of type %s, expected %s",
+			name, forModel, f.FieldDef.Type(), typ,
+		))
+	}
+
+	return f
+}
+
+// ColumnType returns the type used to determine the column type of the field.
+func (f *JSONField[T]) ColumnType() reflect.Type {
+	return reflect.TypeOf(drivers.JSON(nil))
+}
+
+func (f *JSONField[T]) ProvideLookup(inf *expr.ExpressionInfo, name string) (expr.Lookup, bool) {
+	return expr.JSONLookups.ProvideLookup(inf, name)
+}
+
+func (f *JSONField[T]) ProvideTransform(inf *expr.ExpressionInfo, name string) (expr.LookupTransform, bool) {
+	return expr.JSONLookups.ProvideTransform(inf, name)
+}
+
+// Scan unmarshals the JSON value from the database into a new T.
+//
+// Some drivers already decode JSON columns, these values are marshalled again before
+// being unmarshalled into T.
+func (f *JSONField[T]) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		return f.FieldDef.Scan(nil)
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		data, err = json.Marshal(v)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %T for field %q", src, f.Name())
+		}
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal JSON for field %q", f.Name())
+	}
+
+	return f.FieldDef.SetValue(value, true)
+}
+
+// Value marshals the value of the field to JSON.
+//
+// If the value is nil and the field allows null values, nil is returned.
+func (f *JSONField[T]) Value() (driver.Value, error) {
+	var value = f.GetValue()
+	if isNilValue(value) {
+		if f.AllowNull() {
+			return nil, nil
+		}
+		value = f.GetDefault()
+	}
+
+	var data, err = json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal JSON for field %q", f.Name())
+	}
+
+	return string(data), nil
+}
+
+func isNilValue(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	var rVal = reflect.ValueOf(v)
+	switch rVal.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return rVal.IsNil()
+	}
+	return false
+}
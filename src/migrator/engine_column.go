@@ -164,6 +164,10 @@ func (c *Column) FieldType() reflect.Type {
 		}
 	}
 
+	if f, ok := field.(CanColumnType); ok {
+		return f.ColumnType()
+	}
+
 	var fieldType = field.Type()
 	if field.Type().Implements(reflect.TypeOf((*attrs.Definer)(nil)).Elem()) {
 		// if the field is a definer, we return the type of the underlying object
@@ -3,6 +3,7 @@ package migrator
 import (
 	"context"
 	"database/sql"
+	"reflect"
 
 	"github.com/Nigel2392/go-django/src/core/attrs"
 )
@@ -29,6 +30,15 @@ type CanModelMigrate interface {
 	CanMigrate() bool
 }
 
+// CanColumnType can be implemented by fields which store their value in the database
+// as a different type than the Go type of the field.
+//
+// The column type of the field is then determined by the returned type,
+// I.E. a JSON field returns the type of [drivers.JSON] regardless of the type it unmarshals into.
+type CanColumnType interface {
+	ColumnType() reflect.Type
+}
+
 type SchemaEditor interface {
 	Setup() error
 	StoreMigration(appName string, modelName string, migrationName string) error
@@ -37,6 +37,7 @@ func init() {
 	migrator.RegisterColumnType(&drivers.DriverMySQL{}, drivers.Bool(false), Type__bool)
 	migrator.RegisterColumnType(&drivers.DriverMySQL{}, drivers.Float(0.0), Type__float)
 	migrator.RegisterColumnType(&drivers.DriverMySQL{}, drivers.Time{}, Type__datetime)
+	migrator.RegisterColumnType(&drivers.DriverMySQL{}, drivers.JSON(nil), Type__json)
 
 	migrator.RegisterColumnType(&drivers.DriverMySQL{}, (*contenttypes.ContentType)(nil), Type__string)
 	migrator.RegisterColumnType(&drivers.DriverMySQL{}, contenttypes.BaseContentType[attrs.Definer]{}, Type__string)
@@ -67,6 +68,7 @@ func init() {
 	migrator.RegisterColumnType(&drivers.DriverMariaDB{}, drivers.Bool(false), Type__bool)
 	migrator.RegisterColumnType(&drivers.DriverMariaDB{}, drivers.Float(0.0), Type__float)
 	migrator.RegisterColumnType(&drivers.DriverMariaDB{}, drivers.Time{}, Type__datetime)
+	migrator.RegisterColumnType(&drivers.DriverMariaDB{}, drivers.JSON(nil), Type__json)
 
 	migrator.RegisterColumnType(&drivers.DriverMariaDB{}, (*contenttypes.ContentType)(nil), Type__string)
 	migrator.RegisterColumnType(&drivers.DriverMariaDB{}, contenttypes.BaseContentType[attrs.Definer]{}, Type__string)
@@ -161,3 +163,7 @@ func Type__bool(c *migrator.Column) string {
 func Type__datetime(c *migrator.Column) string {
 	return "TIMESTAMP"
 }
+
+func Type__json(c *migrator.Column) string {
+	return "JSON"
+}
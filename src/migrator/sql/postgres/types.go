@@ -37,6 +37,7 @@ func init() {
 	migrator.RegisterColumnType(&drivers.DriverPostgres{}, drivers.Bool(false), Type__bool)
 	migrator.RegisterColumnType(&drivers.DriverPostgres{}, drivers.Float(0.0), Type__float)
 	migrator.RegisterColumnType(&drivers.DriverPostgres{}, drivers.Time{}, Type__datetime)
+	migrator.RegisterColumnType(&drivers.DriverPostgres{}, drivers.JSON(nil), Type__json)
 
 	migrator.RegisterColumnType(&drivers.DriverPostgres{}, (*contenttypes.ContentType)(nil), Type__string)
 	migrator.RegisterColumnType(&drivers.DriverPostgres{}, contenttypes.BaseContentType[attrs.Definer]{}, Type__string)
@@ -130,3 +131,7 @@ func Type__bool(c *migrator.Column) string {
 func Type__datetime(c *migrator.Column) string {
 	return "TIMESTAMP"
 }
+
+func Type__json(c *migrator.Column) string {
+	return "JSONB"
+}
@@ -35,6 +35,7 @@ func init() {
 	migrator.RegisterColumnType(&drivers.DriverSQLite{}, drivers.Bool(false), Type__bool)
 	migrator.RegisterColumnType(&drivers.DriverSQLite{}, drivers.Float(0.0), Type__float)
 	migrator.RegisterColumnType(&drivers.DriverSQLite{}, drivers.Time{}, Type__datetime)
+	migrator.RegisterColumnType(&drivers.DriverSQLite{}, drivers.JSON(nil), Type__json)
 
 	migrator.RegisterColumnType(&drivers.DriverSQLite{}, (*contenttypes.ContentType)(nil), Type__string)
 	migrator.RegisterColumnType(&drivers.DriverSQLite{}, contenttypes.BaseContentType[attrs.Definer]{}, Type__string)
@@ -74,3 +75,7 @@ func Type__bool(c *migrator.Column) string {
 func Type__datetime(c *migrator.Column) string {
 	return "TIMESTAMP"
 }
+
+func Type__json(c *migrator.Column) string {
+	return "TEXT"
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"reflect"
+	"slices"
 
 	queries "github.com/Nigel2392/go-django-queries/src"
 	"github.com/Nigel2392/go-django-queries/src/drivers"
@@ -29,6 +30,8 @@ var (
 	_ queries.DataModel                    = &Model{}
 	_ queries.Annotator                    = &Model{}
 	_ queries.ThroughModelSetter           = &Model{}
+	_ queries.DeferredFieldsSetter         = &Model{}
+	_ queries.DeferredFieldsGetter         = &Model{}
 	_ queries.ActsAfterSave                = &Model{}
 	_ queries.ActsAfterQuery               = &Model{}
 	_ attrs.CanSignalChanged               = &Model{}
@@ -138,7 +141,13 @@ func (m *Model) setupInitialState() {
 		return
 	}
 
+	// deferred fields are still not loaded from the database
+	// when the state is reinitialized, I.E. after saving the model
+	var previous = m.internals.state
 	m.internals.state = initState(m)
+	if previous != nil {
+		maps.Copy(m.internals.state.deferred, previous.deferred)
+	}
 }
 
 // onChange is a callback that is called when the model changes.
@@ -709,6 +718,84 @@ func (m *Model) SetThroughModel(throughModel attrs.Definer) {
 	m.ThroughModel = throughModel
 }
 
+// SetDeferredFields marks the fields as deferred, these fields were
+// not loaded from the database and hold their zero value.
+//
+// Deferred fields are not saved unless they are changed, see [Model.LoadDeferred] to load them.
+func (m *Model) SetDeferredFields(fields []string) {
+	m.State().setDeferred(fields)
+}
+
+// DeferredFields returns the names of the deferred fields which were not changed since the model was loaded.
+//
+// These fields are skipped when the model is updated with [queries.QuerySet.Update].
+func (m *Model) DeferredFields() []string {
+	var state = m.State()
+	return slices.DeleteFunc(state.DeferredFields(), state.HasChanged)
+}
+
+// LoadDeferred loads the deferred fields of the model from the database.
+//
+// If no fields are provided, all deferred fields are loaded.
+// The loaded fields are no longer deferred and are not marked as changed.
+func (m *Model) LoadDeferred(ctx context.Context, fields ...string) error {
+	var state = m.State()
+	if len(fields) == 0 {
+		fields = state.DeferredFields()
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var (
+		this = m.Object()
+		defs = this.FieldDefs()
+		pk   = defs.Primary()
+	)
+
+	if pk == nil {
+		return fmt.Errorf(
+			"cannot load deferred fields of %T: model has no primary key",
+			this,
+		)
+	}
+
+	var row, err = queries.GetQuerySetWithContext(ctx, this).
+		Select(append([]any{pk.Name()}, attrs.InterfaceList(fields)...)...).
+		Filter(pk.Name(), pk.GetValue()).
+		Get()
+	if err != nil {
+		return fmt.Errorf(
+			"failed to load deferred fields of %T: %w",
+			this, err,
+		)
+	}
+
+	var loadedDefs = row.Object.FieldDefs()
+	for _, name := range fields {
+		var field, ok = defs.Field(name)
+		if !ok {
+			return fmt.Errorf(
+				"cannot load deferred field %q of %T: %w",
+				name, this, query_errors.ErrFieldNotFound,
+			)
+		}
+
+		loaded, _ := loadedDefs.Field(name)
+		if err := field.SetValue(loaded.GetValue(), true); err != nil {
+			return fmt.Errorf(
+				"failed to set deferred field %q of %T: %w",
+				name, this, err,
+			)
+		}
+
+		state.loaded(name)
+	}
+
+	return nil
+}
+
 // Annotate adds annotations to the model.
 // Annotations are key-value pairs that can be used to store additional
 // information about the model, such as database annotations or custom data.
@@ -843,6 +930,12 @@ func (m *Model) SaveObject(ctx context.Context, cnf SaveConfig) (err error) {
 			continue
 		}
 
+		// The field was not loaded from the database, saving
+		// it would overwrite the stored value with its zero value.
+		if !hasChanged && m.internals.state.Deferred(head.Value.Name()) {
+			continue
+		}
+
 		// Check if the field is a Saver or a SaveableField.
 		// If it is a Saver, we need to panic and inform the user
 		// that they need to use a ContextSaver to maintain transaction integrity.
@@ -870,10 +963,10 @@ func (m *Model) SaveObject(ctx context.Context, cnf SaveConfig) (err error) {
 		return nil
 	}
 
-	// Start transaction, if one was already started this is a no-op.
+	// Start a transaction, if one was already started the model is saved in it.
 	var transaction drivers.Transaction
 	if queries.QUERYSET_CREATE_IMPLICIT_TRANSACTION {
-		ctx, transaction, err = queries.StartTransaction(ctx)
+		ctx, transaction, err = queries.GetOrCreateTransaction(ctx)
 		if err != nil {
 			return fmt.Errorf(
 				"failed to start transaction for model %T: %w",
@@ -944,7 +1037,10 @@ func (m *Model) SaveObject(ctx context.Context, cnf SaveConfig) (err error) {
 		}
 	}
 
-	// reset the state after saving
+	// reset the state after saving, saved fields are no longer deferred
+	for _, name := range selectFields {
+		m.internals.state.loaded(name.(string))
+	}
 	m.internals.state.Reset()
 	m.internals.fromDB = true
 
@@ -24,6 +24,10 @@ type ModelState struct {
 	// which are retrieved from the fields Value() method,
 	// which normally returns the database compatible value
 	initial map[string]interface{}
+
+	// deferred fields, these were not loaded from the database
+	// and hold their zero value, see [queries.QuerySet.Defer]
+	deferred map[string]struct{}
 }
 
 // initState initializes the model state for the given model.
@@ -33,7 +37,8 @@ func initState(model *Model) *ModelState {
 	}
 
 	var state = &ModelState{
-		model: model,
+		model:    model,
+		deferred: make(map[string]struct{}),
 	}
 
 	state.Reset()
@@ -128,6 +133,69 @@ func (m *ModelState) HasChanged(fieldName string) bool {
 	return false
 }
 
+// Deferred reports whether the field was deferred when the model was
+// loaded from the database, meaning the field holds its zero value.
+//
+// See [queries.QuerySet.Defer] and [queries.QuerySet.Only].
+func (m *ModelState) Deferred(fieldName string) bool {
+	if m == nil {
+		return false
+	}
+
+	var _, ok = m.deferred[fieldName]
+	return ok
+}
+
+// DeferredFields returns the names of the deferred fields of the model,
+// in the order of the model's field definitions.
+func (m *ModelState) DeferredFields() []string {
+	if m == nil || len(m.deferred) == 0 {
+		return nil
+	}
+
+	if m.model == nil {
+		panic("model state is not properly initialized: model is nil")
+	}
+
+	var fields = make([]string, 0, len(m.deferred))
+	for head := m.model.internals.defs.ObjectFields.Front(); head != nil; head = head.Next() {
+		if _, ok := m.deferred[head.Value.Name()]; ok {
+			fields = append(fields, head.Value.Name())
+		}
+	}
+	return fields
+}
+
+// setDeferred marks the fields as deferred.
+func (m *ModelState) setDeferred(fields []string) {
+	if m == nil {
+		return
+	}
+
+	for _, name := range fields {
+		m.deferred[name] = struct{}{}
+	}
+}
+
+// loaded marks a deferred field as loaded,
+// its current value becomes the initial value.
+func (m *ModelState) loaded(fieldName string) {
+	if m == nil {
+		return
+	}
+
+	if m.model == nil {
+		panic("model state is not properly initialized: model is nil")
+	}
+
+	delete(m.deferred, fieldName)
+	delete(m.changed, fieldName)
+
+	if field, ok := m.model.internals.defs.Field(fieldName); ok {
+		m.initial[fieldName] = field.GetValue()
+	}
+}
+
 // InitialValue returns the initial value of a field in the model's state.
 func (m *ModelState) InitialValue(fieldName string) (interface{}, bool) {
 	if m == nil {
@@ -149,6 +217,8 @@ func (m *ModelState) InitialValue(fieldName string) (interface{}, bool) {
 // Reset clears the changed fields and initial values and
 // reinitializes the initial values from the model's definitions.
 // This is useful when the model is saved or reset.
+//
+// Deferred fields are kept, they are still not loaded from the database.
 func (m *ModelState) Reset() {
 	if m == nil {
 		return
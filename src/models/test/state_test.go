@@ -447,3 +447,185 @@ func TestState(t *testing.T) {
 	})
 
 }
+
+func TestStateDeferredFields(t *testing.T) {
+	var tables = quest.Table(t,
+		&ImageModel{},
+		&StatefulModel{},
+	)
+
+	tables.Create()
+	defer tables.Drop()
+
+	var model = models.Setup(&StatefulModel{
+		FirstName: "John",
+		LastName:  "Doe",
+		Age:       30,
+		BinData:   []byte{1, 2, 3},
+		MapData: JSONMap{
+			"key1": "value1",
+		},
+	})
+
+	if err := model.Save(context.Background()); err != nil {
+		t.Fatalf("Failed to save model: %v", err)
+	}
+
+	var get = func(t *testing.T) *StatefulModel {
+		var row, err = queries.GetQuerySet(&StatefulModel{}).
+			Filter("ID", model.ID).
+			Get()
+		if err != nil {
+			t.Fatalf("Failed to get StatefulModel from DB: %v", err)
+		}
+		return row.Object
+	}
+
+	t.Run("Defer", func(t *testing.T) {
+		var qs = queries.GetQuerySet(&StatefulModel{}).
+			Defer("BinData", "MapData").
+			Filter("ID", model.ID)
+
+		var row, err = qs.Get()
+		if err != nil {
+			t.Fatalf("Failed to get StatefulModel from DB: %v", err)
+		}
+
+		var obj = row.Object
+		if obj.FirstName != "John" || obj.Age != 30 {
+			t.Errorf("Expected non-deferred fields to be loaded, got: %+v", obj)
+		}
+
+		if obj.BinData != nil || obj.MapData != nil {
+			t.Errorf("Expected deferred fields to be zero, got: %v, %v", obj.BinData, obj.MapData)
+		}
+
+		var state = obj.State()
+		if !state.Deferred("BinData") || !state.Deferred("MapData") || state.Deferred("FirstName") {
+			t.Errorf("Expected BinData and MapData to be deferred, got: %v", state.DeferredFields())
+		}
+
+		if fmt.Sprint(state.DeferredFields()) != "[BinData MapData]" {
+			t.Errorf("Expected deferred fields to be [BinData MapData], got: %v", state.DeferredFields())
+		}
+
+		t.Run("SaveExcludesDeferred", func(t *testing.T) {
+			obj.FirstName = "Jane"
+			if err := obj.Save(context.Background()); err != nil {
+				t.Fatalf("Failed to save model: %v", err)
+			}
+
+			var dbObj = get(t)
+			if dbObj.FirstName != "Jane" {
+				t.Errorf("Expected FirstName to be 'Jane', got: %s", dbObj.FirstName)
+			}
+
+			if len(dbObj.BinData) != 3 || dbObj.MapData["key1"] != "value1" {
+				t.Errorf("Expected deferred fields to be unchanged, got: %v, %v", dbObj.BinData, dbObj.MapData)
+			}
+
+			if !obj.State().Deferred("BinData") {
+				t.Error("Expected BinData to still be deferred after save")
+			}
+		})
+
+		t.Run("LoadDeferred", func(t *testing.T) {
+			if err := obj.LoadDeferred(context.Background(), "BinData"); err != nil {
+				t.Fatalf("Failed to load deferred fields: %v", err)
+			}
+
+			if len(obj.BinData) != 3 || obj.BinData[0] != 1 {
+				t.Errorf("Expected BinData to be loaded, got: %v", obj.BinData)
+			}
+
+			var state = obj.State()
+			if state.Deferred("BinData") || !state.Deferred("MapData") {
+				t.Errorf("Expected only MapData to be deferred, got: %v", state.DeferredFields())
+			}
+
+			if state.Changed(true) {
+				t.Error("Expected state to be unchanged after loading deferred fields")
+			}
+
+			if err := obj.LoadDeferred(context.Background()); err != nil {
+				t.Fatalf("Failed to load deferred fields: %v", err)
+			}
+
+			if obj.MapData["key1"] != "value1" || len(state.DeferredFields()) != 0 {
+				t.Errorf("Expected all fields to be loaded, got: %v (%v)", obj.MapData, state.DeferredFields())
+			}
+		})
+	})
+
+	t.Run("Only", func(t *testing.T) {
+		var row, err = queries.GetQuerySet(&StatefulModel{}).
+			Only("FirstName").
+			Filter("ID", model.ID).
+			Get()
+		if err != nil {
+			t.Fatalf("Failed to get StatefulModel from DB: %v", err)
+		}
+
+		var obj = row.Object
+		if obj.ID != model.ID || obj.FirstName != "Jane" {
+			t.Errorf("Expected ID and FirstName to be loaded, got: %+v", obj)
+		}
+
+		if obj.LastName != "" || obj.Age != 0 || obj.BinData != nil {
+			t.Errorf("Expected other fields to be zero, got: %+v", obj)
+		}
+
+		if fmt.Sprint(obj.State().DeferredFields()) != "[LastName Age BinData MapData Image]" {
+			t.Errorf("Expected all other fields to be deferred, got: %v", obj.State().DeferredFields())
+		}
+
+		t.Run("SaveChangedDeferred", func(t *testing.T) {
+			obj.LastName = "Smith"
+			if err := obj.Save(context.Background()); err != nil {
+				t.Fatalf("Failed to save model: %v", err)
+			}
+
+			var dbObj = get(t)
+			if dbObj.FirstName != "Jane" || dbObj.LastName != "Smith" || dbObj.Age != 30 || len(dbObj.BinData) != 3 {
+				t.Errorf("Expected only LastName to be updated, got: %+v", dbObj)
+			}
+
+			if obj.State().Deferred("LastName") {
+				t.Error("Expected LastName to no longer be deferred after save")
+			}
+		})
+
+		t.Run("UpdateExcludesDeferred", func(t *testing.T) {
+			var row, err = queries.GetQuerySet(&StatefulModel{}).
+				Only("FirstName").
+				Filter("ID", model.ID).
+				Get()
+			if err != nil {
+				t.Fatalf("Failed to get StatefulModel from DB: %v", err)
+			}
+
+			var obj = row.Object
+			obj.FirstName = "Janet"
+			if fmt.Sprint(obj.DeferredFields()) != "[LastName Age BinData MapData Image]" {
+				t.Errorf("Expected all other fields to be deferred, got: %v", obj.DeferredFields())
+			}
+
+			updated, err := queries.GetQuerySet(&StatefulModel{}).
+				Select("*").
+				Filter("ID", model.ID).
+				Update(obj)
+			if err != nil {
+				t.Fatalf("Failed to update model: %v", err)
+			}
+
+			if updated != 1 {
+				t.Fatalf("Expected 1 row to be updated, got %d", updated)
+			}
+
+			var dbObj = get(t)
+			if dbObj.FirstName != "Janet" || dbObj.LastName != "Smith" || dbObj.Age != 30 || len(dbObj.BinData) != 3 {
+				t.Errorf("Expected only FirstName to be updated, got: %+v", dbObj)
+			}
+		})
+	})
+}
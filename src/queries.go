@@ -355,6 +355,23 @@ type ThroughModelSetter interface {
 	SetThroughModel(throughModel attrs.Definer)
 }
 
+// A model can adhere to this interface to keep track of the fields
+// which were deferred with [QuerySet.Defer] or [QuerySet.Only].
+//
+// The deferred fields were not loaded from the database and hold their zero value.
+type DeferredFieldsSetter interface {
+	SetDeferredFields(fields []string)
+}
+
+// A model can adhere to this interface to report the fields which were
+// deferred with [QuerySet.Defer] or [QuerySet.Only] and were not changed since.
+//
+// The deferred fields are skipped when the object is updated with [QuerySet.Update] or [QuerySet.BulkUpdate],
+// writing them would overwrite the stored values with their zero values.
+type DeferredFieldsGetter interface {
+	DeferredFields() []string
+}
+
 // A model can adhere to this interface to indicate that the queries package
 // should not automatically save or delete the model to/from the database when
 // `django/models.SaveObject()` or `django/models.DeleteObject()` is called.
@@ -499,7 +516,9 @@ type QueryCompiler interface {
 	SupportsReturning() drivers.SupportsReturningType
 
 	// StartTransaction starts a new transaction.
-	StartTransaction(ctx context.Context) (drivers.Transaction, error)
+	//
+	// The options are optional, if nil the default options of the database are used.
+	StartTransaction(ctx context.Context, opts *drivers.TxOptions) (drivers.Transaction, error)
 
 	// WithTransaction wraps the transaction and binds it to the compiler.
 	WithTransaction(tx drivers.Transaction) (drivers.Transaction, error)
@@ -523,6 +542,18 @@ type QueryCompiler interface {
 		internals *QuerySetInternals,
 	) CompiledQuery[[][]interface{}]
 
+	// BuildCompoundQuery builds a compound select query with the given parameters.
+	//
+	// The select query of the queryset is combined with the select queries of
+	// [QuerySetInternals.Combined] using the operator of each [CombinedQuery].
+	//
+	// Ordering, limit and offset of the queryset apply to the combined result.
+	BuildCompoundQuery(
+		ctx context.Context,
+		qs *QuerySet[attrs.Definer],
+		internals *QuerySetInternals,
+	) CompiledQuery[[][]interface{}]
+
 	// BuildCountQuery builds a count query with the given parameters.
 	BuildCountQuery(
 		ctx context.Context,
@@ -530,6 +561,17 @@ type QueryCompiler interface {
 		internals *QuerySetInternals,
 	) CompiledQuery[int64]
 
+	// BuildExplainQuery builds a query which explains the select query of the queryset.
+	//
+	// The select query (or compound query, see [QueryCompiler.BuildCompoundQuery])
+	// is wrapped in the EXPLAIN statement supported by the database.
+	BuildExplainQuery(
+		ctx context.Context,
+		qs *QuerySet[attrs.Definer],
+		internals *QuerySetInternals,
+		options ExplainOptions,
+	) CompiledQuery[*QueryPlan]
+
 	// BuildCreateQuery builds a create query with the given parameters.
 	BuildCreateQuery(
 		ctx context.Context,
@@ -32,4 +32,9 @@ const (
 	ErrFieldNotFound errs.Error = "field not found in model definition"
 	ErrNoUniqueKey   errs.Error = "could not find unique key for model"
 	ErrSaveFailed    errs.Error = "failed to save model"
+	ErrInvalidCursor errs.Error = "invalid pagination cursor"
+
+	// Returned by [queries.QuerySet.Iterator] when the query selects multi-valued relations,
+	// the related objects of a single object can be spread across multiple rows.
+	ErrDedupeRequired errs.Error = "query requires deduplication across rows"
 )
@@ -80,6 +80,23 @@ type QuerySetInternals struct {
 	Offset      int
 	ForUpdate   bool
 	Distinct    bool
+	Prefetch    []Prefetch
+	Combined    []CombinedQuery
+	CTEs        []CTE
+	OnConflict  *OnConflict
+
+	// ForUpdateOptions configures the locking clause if ForUpdate is set.
+	ForUpdateOptions ForUpdateOptions
+
+	// Deferred are the names of the fields of the model which are not loaded, see [QuerySet.Defer].
+	Deferred []string
+
+	// DistinctOn are the columns of the `DISTINCT ON` clause, see [QuerySet.DistinctOn].
+	DistinctOn []expr.TableColumn
+
+	// OuterInfo is the expression info of the outer query if the queryset is compiled as a subquery,
+	// it is used to resolve [expr.OuterRef] expressions.
+	OuterInfo *expr.ExpressionInfo
 
 	joinsMap map[string]struct{}
 	proxyMap map[string]struct{}
@@ -99,6 +116,11 @@ func (i *QuerySetInternals) AddJoin(join JoinDef) {
 	}
 
 	var key = join.JoinDefCondition.String()
+	if join.Subquery != nil {
+		// a derived table might not have a join condition
+		key = "subquery:" + join.Table.Alias
+	}
+
 	if _, exists := i.joinsMap[key]; !exists {
 		i.joinsMap[key] = struct{}{}
 		i.Joins = append(i.Joins, join)
@@ -145,6 +167,7 @@ type QuerySet[T attrs.Definer] struct {
 	latestQuery  QueryInfo
 	useCache     bool
 	cached       any
+	cache        *queryCache
 }
 
 // GetQuerySet creates a new QuerySet for the given model.
@@ -288,6 +311,7 @@ func ChangeObjectsType[OldT, NewT attrs.Definer](qs *QuerySet[OldT]) *QuerySet[N
 		explicitSave: qs.explicitSave,
 		useCache:     qs.useCache,
 		cached:       qs.cached,
+		cache:        qs.cache,
 		internals:    qs.internals,
 		context:      qs.context,
 	}
@@ -337,8 +361,8 @@ func (qs *QuerySet[T]) WithContext(ctx context.Context) *QuerySet[T] {
 		panic("QuerySet: context cannot be nil")
 	}
 
-	var tx, dbName, ok = transactionFromContext(ctx)
-	if ok && dbName == qs.compiler.DatabaseName() {
+	var tx, ok = transactionForDatabase(ctx, qs.compiler.DatabaseName())
+	if ok {
 		// if the context already has a transaction, use it
 		qs.compiler.WithTransaction(tx)
 	}
@@ -351,7 +375,7 @@ func (qs *QuerySet[T]) WithContext(ctx context.Context) *QuerySet[T] {
 //
 // It returns a transaction object which can be used to commit or rollback the transaction.
 func (qs *QuerySet[T]) StartTransaction(ctx context.Context) (drivers.Transaction, error) {
-	var tx, err = qs.compiler.StartTransaction(ctx)
+	var tx, err = qs.compiler.StartTransaction(ctx, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "StartTransaction: failed to start transaction")
 	}
@@ -405,9 +429,21 @@ func (qs *QuerySet[T]) Clone() *QuerySet[T] {
 			Limit:       qs.internals.Limit,
 			Offset:      qs.internals.Offset,
 			ForUpdate:   qs.internals.ForUpdate,
-			Distinct:    qs.internals.Distinct,
-			joinsMap:    maps.Clone(qs.internals.joinsMap),
-			proxyMap:    maps.Clone(qs.internals.proxyMap),
+			ForUpdateOptions: ForUpdateOptions{
+				NoWait:     qs.internals.ForUpdateOptions.NoWait,
+				SkipLocked: qs.internals.ForUpdateOptions.SkipLocked,
+				Of:         slices.Clone(qs.internals.ForUpdateOptions.Of),
+				Share:      qs.internals.ForUpdateOptions.Share,
+			},
+			Distinct:   qs.internals.Distinct,
+			Prefetch:   slices.Clone(qs.internals.Prefetch),
+			Combined:   slices.Clone(qs.internals.Combined),
+			CTEs:       slices.Clone(qs.internals.CTEs),
+			Deferred:   slices.Clone(qs.internals.Deferred),
+			DistinctOn: slices.Clone(qs.internals.DistinctOn),
+			OuterInfo:  qs.internals.OuterInfo,
+			joinsMap:   maps.Clone(qs.internals.joinsMap),
+			proxyMap:   maps.Clone(qs.internals.proxyMap),
 
 			// annotations are not cloned
 			// this is to prevent the previous annotations
@@ -415,6 +451,7 @@ func (qs *QuerySet[T]) Clone() *QuerySet[T] {
 		},
 		explicitSave: qs.explicitSave,
 		useCache:     qs.useCache,
+		cache:        qs.cache,
 		compiler:     qs.compiler,
 		context:      qs.context,
 
@@ -604,10 +641,11 @@ func (qs *QuerySet[T]) unpackFields(fields ...any) (infos []FieldInfo[attrs.Fiel
 			}
 		}
 
-		// The field might be a relation
+		// The field might be a relation, a relation on the model
+		// itself is used as its column, I.E. `GroupBy("User")`
 		var rel = field.Rel()
 
-		if (rel != nil) || (len(chain) > 0 || isRelated) {
+		if len(chain) > 0 || isRelated {
 			var relType attrs.RelationType
 			if rel != nil {
 				relType = rel.Type()
@@ -1219,7 +1257,12 @@ fieldsLoop:
 		if err != nil {
 			field, ok := qs.internals.Annotations.Get(selectedField)
 			if !ok {
-				panic(err)
+				// the column of a joined subquery is selected like an annotation
+				var col, isColumn = qs.internals.subqueryColumn(selectedField)
+				if !isColumn {
+					panic(err)
+				}
+				field = newQueryField[any](selectedField, CTEColumn(col.TableOrAlias, col.FieldAlias))
 			}
 			qs.internals.Fields = append(qs.internals.Fields, &FieldInfo[attrs.FieldDefinition]{
 				Table: Table{
@@ -1318,6 +1361,7 @@ fieldsLoop:
 		qs.internals.Joins = append(qs.internals.Joins, subJoins...)
 	}
 
+	qs.deferFields()
 	return qs
 }
 
@@ -1384,6 +1428,14 @@ func (qs *QuerySet[T]) compileOrderBy(fields ...string) []OrderBy {
 			ord = strings.TrimPrefix(ord, "-")
 		}
 
+		if col, ok := qs.internals.subqueryColumn(ord); ok {
+			orderBy = append(orderBy, OrderBy{
+				Column: *col,
+				Desc:   desc,
+			})
+			continue
+		}
+
 		var obj, _, field, _, aliases, _, err = internal.WalkFields(
 			qs.internals.Model.Object, ord, qs.AliasGen,
 		)
@@ -1459,12 +1511,56 @@ func (qs *QuerySet[T]) Offset(n int) *QuerySet[T] {
 	return nqs
 }
 
+// ForUpdateOptions configures the row locking clause of a query, see [QuerySet.ForUpdate].
+type ForUpdateOptions struct {
+	// NoWait makes the query fail instead of waiting for rows which are locked by another transaction.
+	NoWait bool
+
+	// SkipLocked skips rows which are locked by another transaction instead of waiting for them.
+	//
+	// It cannot be combined with NoWait.
+	SkipLocked bool
+
+	// Of limits the lock to the tables of the given relations, "self" refers to the table of the queryset's model.
+	//
+	// The relations must be selected, I.E. `Of: []string{"self", "Author"}` for a queryset which selects `Author.*`.
+	// This is not supported by MariaDB.
+	Of []string
+
+	// Share acquires a shared lock instead of an exclusive lock,
+	// other transactions can read but not modify the locked rows.
+	Share bool
+}
+
 // ForUpdate is used to lock the rows returned by a query for update.
 //
 // It is used to prevent other transactions from modifying the rows until the current transaction is committed or rolled back.
-func (qs *QuerySet[T]) ForUpdate() *QuerySet[T] {
+//
+// The options can be used to configure the locking clause, I.E.:
+//
+//	qs.ForUpdate(queries.ForUpdateOptions{SkipLocked: true})
+//
+// will result in `FOR UPDATE SKIP LOCKED`, which is useful to let multiple workers claim jobs from a single table.
+// It panics if both NoWait and SkipLocked are set.
+//
+// SQLite does not support row locking, a write transaction locks the whole database instead.
+// The locking clause is omitted for SQLite, ForUpdate is a no-op.
+func (qs *QuerySet[T]) ForUpdate(options ...ForUpdateOptions) *QuerySet[T] {
+	var opts ForUpdateOptions
+	if len(options) > 1 {
+		panic("QuerySet.ForUpdate: only one ForUpdateOptions can be provided")
+	}
+	if len(options) == 1 {
+		opts = options[0]
+	}
+
+	if opts.NoWait && opts.SkipLocked {
+		panic("QuerySet.ForUpdate: NoWait and SkipLocked cannot be combined")
+	}
+
 	var nqs = qs.Clone()
 	nqs.internals.ForUpdate = true
+	nqs.internals.ForUpdateOptions = opts
 	return nqs
 }
 
@@ -1477,6 +1573,36 @@ func (qs *QuerySet[T]) Distinct() *QuerySet[T] {
 	return nqs
 }
 
+// DistinctOn is used to only select the first row of each group of rows
+// which have the same values for the given fields, I.E. the latest post per author:
+//
+//	qs.DistinctOn("Author").OrderBy("Author", "-CreatedAt")
+//
+// The leading fields passed to [QuerySet.OrderBy] must match the fields passed to DistinctOn,
+// the remaining fields of the ordering decide which row of each group is selected.
+//
+// This compiles to `SELECT DISTINCT ON (...)` for PostgreSQL. Other databases do not support
+// `DISTINCT ON`, it is emulated by filtering on the primary keys of the rows numbered first by `ROW_NUMBER()`.
+// The emulation requires the model to have a primary key and cannot be combined with [QuerySet.GroupBy].
+func (qs *QuerySet[T]) DistinctOn(fields ...string) *QuerySet[T] {
+	if len(fields) == 0 {
+		panic(fmt.Errorf("DistinctOn: no fields provided for %T", qs.internals.Model.Object))
+	}
+
+	for _, field := range fields {
+		if strings.HasPrefix(strings.TrimSpace(field), "-") {
+			panic(fmt.Errorf("DistinctOn: field %q cannot be ordered, use OrderBy instead", field))
+		}
+	}
+
+	var nqs = qs.Clone()
+	nqs.internals.DistinctOn = make([]expr.TableColumn, 0, len(fields))
+	for _, ord := range nqs.compileOrderBy(fields...) {
+		nqs.internals.DistinctOn = append(nqs.internals.DistinctOn, ord.Column)
+	}
+	return nqs
+}
+
 // ExplicitSave is used to indicate that the save operation should be explicit.
 //
 // It is used to prevent the automatic save operation from being performed on the model.
@@ -1583,13 +1709,27 @@ func (qs *QuerySet[T]) queryAll(fields ...any) CompiledQuery[[][]interface{}] {
 
 	if len(fields) > 0 {
 		*qs = *qs.Select(fields...)
+
+		// combined querysets must select the same columns
+		for i, combined := range qs.internals.Combined {
+			qs.internals.Combined[i].QuerySet = combined.QuerySet.Select(fields...)
+		}
 	}
 
-	var query = qs.compiler.BuildSelectQuery(
-		qs.context,
-		ChangeObjectsType[T, attrs.Definer](qs),
-		qs.internals,
-	)
+	var query CompiledQuery[[][]interface{}]
+	if len(qs.internals.Combined) > 0 {
+		query = qs.compiler.BuildCompoundQuery(
+			qs.context,
+			ChangeObjectsType[T, attrs.Definer](qs),
+			qs.internals,
+		)
+	} else {
+		query = qs.compiler.BuildSelectQuery(
+			qs.context,
+			ChangeObjectsType[T, attrs.Definer](qs),
+			qs.internals,
+		)
+	}
 	qs.latestQuery = query
 
 	return query
@@ -1602,6 +1742,7 @@ func (qs *QuerySet[T]) queryAggregate() CompiledQuery[[][]interface{}] {
 	dereferenced.Offset = 0        // no offset for aggregates
 	dereferenced.ForUpdate = false // no for update for aggregates
 	dereferenced.Distinct = false  // no distinct for aggregates
+	dereferenced.DistinctOn = nil  // no distinct on for aggregates
 	var query = qs.compiler.BuildSelectQuery(
 		qs.context,
 		ChangeObjectsType[T, attrs.Definer](qs),
@@ -1634,6 +1775,11 @@ func (qs *QuerySet[T]) All() (Rows[T], error) {
 	}
 
 	var resultQuery = qs.queryAll()
+	var cacheKey = qs.cacheKey("All", resultQuery)
+	if cached, ok := cacheGet[Rows[T]](qs, cacheKey); ok {
+		return cached, nil
+	}
+
 	var results, err = resultQuery.Exec()
 	if err != nil {
 		return nil, err
@@ -1659,103 +1805,128 @@ func (qs *QuerySet[T]) All() (Rows[T], error) {
 	}
 
 	for resultIndex, row := range results {
-		var (
-			obj        = internal.NewObjectFromIface(qs.internals.Model.Object)
-			scannables = getScannableFields(qs.internals.Fields, obj)
-		)
+		if err := qs.addRow(rows, row, resultIndex); err != nil {
+			return nil, err
+		}
+	}
 
-		var (
-			annotator, _ = obj.(DataModel)
-			annotations  = make(map[string]any)
-			datastore    ModelDataStore
-		)
+	root, err := rows.compile(qs)
+	if err != nil {
+		return nil, err
+	}
 
-		if annotator != nil {
-			datastore = annotator.DataStore()
+	if len(qs.internals.Prefetch) > 0 {
+		if err := qs.prefetchRelated(root); err != nil {
+			return nil, errors.Wrap(err, "QuerySet.All: failed to prefetch related objects")
 		}
+	}
 
-		for j, field := range scannables {
-			f := field.field
-			val := row[j]
+	qs.cacheSet(cacheKey, root)
 
-			if err := f.Scan(val); err != nil {
-				return nil, errors.Wrapf(err, "failed to scan field %q (%T) in %T", f.Name(), f, f.Instance())
-			}
+	return root, nil
+}
 
-			// If it's a virtual field not in the model, store as annotation
-			if vf, ok := f.(AliasField); ok {
-				var (
-					alias = vf.Alias()
-					val   = vf.GetValue()
-				)
-				if alias == "" {
-					alias = f.Name()
-				}
+// addRow scans a single result row into a new object and adds it to the rows structure.
+//
+// The result index is used as unique value for the root object if it has no unique key.
+func (qs *QuerySet[T]) addRow(rows *rows[T], row []interface{}, resultIndex int) error {
+	var (
+		obj        = internal.NewObjectFromIface(qs.internals.Model.Object)
+		scannables = getScannableFields(qs.internals.Fields, obj)
+	)
 
-				// If the value is a byte slice, convert it to a string
-				// It is highly unlikely that a byte slice will be used as an annotation,
-				// thus we convert it to a string in case the database driver returns the wrong type.
-				// This is a workaround for some drivers that return []byte instead of string.
-				// This should also be done in [Aggregate], [Values] and [ValuesList].
-				if bytes, ok := val.([]byte); ok {
-					val = string(bytes)
-				}
+	var (
+		annotator, _ = obj.(DataModel)
+		annotations  = make(map[string]any)
+		datastore    ModelDataStore
+	)
 
-				annotations[alias] = val
+	if annotator != nil {
+		datastore = annotator.DataStore()
+	}
 
-				if datastore != nil {
-					datastore.SetValue(alias, val)
-				}
-			}
+	for j, field := range scannables {
+		f := field.field
+		val := row[j]
+
+		if err := f.Scan(val); err != nil {
+			return errors.Wrapf(err, "failed to scan field %q (%T) in %T", f.Name(), f, f.Instance())
 		}
 
-		var (
-			uniqueValue any
-			throughObj  attrs.Definer
-		)
+		// If it's a virtual field not in the model, store as annotation
+		if vf, ok := f.(AliasField); ok {
+			var (
+				alias = vf.Alias()
+				val   = vf.GetValue()
+			)
+			if alias == "" {
+				alias = f.Name()
+			}
 
-		// required in case the root object has a through relation bound to it
-		if rows.hasRoot() {
-			var rootRow = rows.rootRow(scannables)
-			uniqueValue, err = GetUniqueKey(rootRow.field)
-			switch {
-			case err != nil && errors.Is(err, query_errors.ErrNoUniqueKey) && rows.hasMultiRelations:
-				return nil, errors.Wrapf(
-					err, "failed to get unique key for %T, but has multi relations",
-					rootRow.object,
-				)
-			case err != nil && errors.Is(err, query_errors.ErrNoUniqueKey):
-				// if no unique key is found, we can use the result index as a unique value
-				// this is only valid for the root object, as it is not a relation
-				uniqueValue = resultIndex + 1
+			// If the value is a byte slice, convert it to a string
+			// It is highly unlikely that a byte slice will be used as an annotation,
+			// thus we convert it to a string in case the database driver returns the wrong type.
+			// This is a workaround for some drivers that return []byte instead of string.
+			// This should also be done in [Aggregate], [Values] and [ValuesList].
+			if bytes, ok := val.([]byte); ok {
+				val = string(bytes)
 			}
 
-			// if the root object has a through relation
-			// we should store it in the rows tree for
-			// binding it to the root.
-			throughObj = rootRow.through
-		}
+			annotations[alias] = val
 
-		// fake unique value for the root object is OK
-		if uniqueValue == nil {
-			uniqueValue = resultIndex + 1
+			if datastore != nil {
+				datastore.SetValue(alias, val)
+			}
 		}
+	}
 
-		// add the root object to the rows tree
-		// this has to be done before adding possible duplicate relations
-		rows.addRoot(
-			uniqueValue, obj, throughObj, annotations,
-		)
+	var (
+		uniqueValue any
+		throughObj  attrs.Definer
+	)
 
-		for _, possibleDuplicate := range rows.possibleDuplicates {
-			var chainParts = buildChainParts(
-				scannables[possibleDuplicate.idx],
+	// required in case the root object has a through relation bound to it
+	if rows.hasRoot() {
+		var rootRow = rows.rootRow(scannables)
+		var err error
+		uniqueValue, err = GetUniqueKey(rootRow.field)
+		switch {
+		case err != nil && errors.Is(err, query_errors.ErrNoUniqueKey) && rows.hasMultiRelations:
+			return errors.Wrapf(
+				err, "failed to get unique key for %T, but has multi relations",
+				rootRow.object,
 			)
-			rows.addRelationChain(chainParts)
+		case err != nil && errors.Is(err, query_errors.ErrNoUniqueKey):
+			// if no unique key is found, we can use the result index as a unique value
+			// this is only valid for the root object, as it is not a relation
+			uniqueValue = resultIndex + 1
 		}
+
+		// if the root object has a through relation
+		// we should store it in the rows tree for
+		// binding it to the root.
+		throughObj = rootRow.through
+	}
+
+	// fake unique value for the root object is OK
+	if uniqueValue == nil {
+		uniqueValue = resultIndex + 1
+	}
+
+	// add the root object to the rows tree
+	// this has to be done before adding possible duplicate relations
+	rows.addRoot(
+		uniqueValue, obj, throughObj, annotations,
+	)
+
+	for _, possibleDuplicate := range rows.possibleDuplicates {
+		var chainParts = buildChainParts(
+			scannables[possibleDuplicate.idx],
+		)
+		rows.addRelationChain(chainParts)
 	}
 
-	return rows.compile(qs)
+	return nil
 }
 
 // Values is used to retrieve a list of dictionaries from the database.
@@ -1770,6 +1941,11 @@ func (qs *QuerySet[T]) Values(fields ...any) ([]map[string]any, error) {
 	}
 
 	var resultQuery = qs.queryAll(fields...)
+	var cacheKey = qs.cacheKey("Values", resultQuery)
+	if cached, ok := cacheGet[[]map[string]any](qs, cacheKey); ok {
+		return cached, nil
+	}
+
 	var results, err = resultQuery.Exec()
 	if err != nil {
 		return nil, err
@@ -1842,6 +2018,8 @@ func (qs *QuerySet[T]) Values(fields ...any) ([]map[string]any, error) {
 		qs.cached = list
 	}
 
+	qs.cacheSet(cacheKey, list)
+
 	return list, nil
 }
 
@@ -1854,6 +2032,11 @@ func (qs *QuerySet[T]) ValuesList(fields ...any) ([][]interface{}, error) {
 	}
 
 	var resultQuery = qs.queryAll(fields...)
+	var cacheKey = qs.cacheKey("ValuesList", resultQuery)
+	if cached, ok := cacheGet[[][]any](qs, cacheKey); ok {
+		return cached, nil
+	}
+
 	var results, err = resultQuery.Exec()
 	if err != nil {
 		return nil, err
@@ -1898,6 +2081,8 @@ func (qs *QuerySet[T]) ValuesList(fields ...any) ([][]interface{}, error) {
 		qs.cached = list
 	}
 
+	qs.cacheSet(cacheKey, list)
+
 	return list, nil
 }
 
@@ -1916,6 +2101,11 @@ func (qs *QuerySet[T]) Aggregate(annotations map[string]expr.Expression) (map[st
 	}
 
 	var query = qs.queryAggregate()
+	var cacheKey = qs.cacheKey("Aggregate", query)
+	if cached, ok := cacheGet[map[string]any](qs, cacheKey); ok {
+		return cached, nil
+	}
+
 	var results, err = query.Exec()
 	if err != nil {
 		return nil, err
@@ -1957,6 +2147,8 @@ func (qs *QuerySet[T]) Aggregate(annotations map[string]expr.Expression) (map[st
 		qs.cached = out
 	}
 
+	qs.cacheSet(cacheKey, out)
+
 	return out, nil
 
 }
@@ -2018,7 +2210,12 @@ func (qs *QuerySet[T]) Get() (*Row[T], error) {
 //
 // It returns the definer object and an error if any occurred.
 //
-// This method executes a transaction to ensure that the object is created only once.
+// This method executes a transaction to ensure that the object is created only once,
+// the row is locked with `SELECT ... FOR UPDATE` if the database supports it.
+//
+// If the object is created concurrently by another transaction, creating it fails with a unique constraint violation,
+// the object is then retrieved again instead of returning the error. If the queryset is already in a postgres transaction,
+// the object is created in a savepoint, a failed insert is rolled back to the savepoint so the transaction can still be used.
 //
 // It panics if the queryset has no where clause.
 func (qs *QuerySet[T]) GetOrCreate(value T) (T, bool, error) {
@@ -2027,6 +2224,24 @@ func (qs *QuerySet[T]) GetOrCreate(value T) (T, bool, error) {
 		panic(query_errors.ErrNoWhereClause)
 	}
 
+	var obj, created, err = qs.getOrCreate(value)
+	if err != nil && drivers.IsUniqueViolation(err) {
+		// The object was created by another transaction after
+		// it was not found, it should be retrievable now.
+		var nqs = qs.Clone()
+		nqs.useCache = false
+		var row, getErr = nqs.Get()
+		qs.latestQuery = nqs.latestQuery
+		if getErr != nil {
+			return *new(T), false, err
+		}
+		return row.Object, false, nil
+	}
+
+	return obj, created, err
+}
+
+func (qs *QuerySet[T]) getOrCreate(value T) (T, bool, error) {
 	// If the queryset is already in a transaction, that transaction will be used
 	// automatically.
 	var tx, err = qs.GetOrCreateTransaction()
@@ -2037,9 +2252,13 @@ func (qs *QuerySet[T]) GetOrCreate(value T) (T, bool, error) {
 	}
 	defer tx.Rollback()
 
-	// Check if the object already exists
-	qs.useCache = false
-	row, err := qs.Get()
+	// Check if the object already exists, the row is locked
+	// keeping any locking options which were set on the queryset
+	var nqs = qs.Clone()
+	nqs.internals.ForUpdate = true
+	nqs.useCache = false
+	row, err := nqs.Get()
+	qs.latestQuery = nqs.latestQuery
 	if err != nil {
 		if errors.Is(err, query_errors.ErrNoRows) {
 			goto create
@@ -2057,8 +2276,7 @@ func (qs *QuerySet[T]) GetOrCreate(value T) (T, bool, error) {
 
 	// Object does not exist, create it
 create:
-	obj, err := qs.Create(value)
-	// obj, err := qs.BulkCreate([]T{value})
+	obj, err := qs.createInSavepoint(value)
 	if err != nil {
 		return *new(T), false, errors.Wrapf(
 			err, "failed to create object %T", qs.internals.Model.Object,
@@ -2067,7 +2285,161 @@ create:
 
 	// Object was created successfully, commit the transaction
 	return obj, true, tx.Commit()
-	// return obj[0], true, commitTransaction()
+}
+
+// UpdateOrCreate is used to update a single row in the database or create it if it does not exist.
+//
+// The lookup is used to filter the queryset, I.E. `map[string]any{"Email": email}`.
+// If a row is found, the fields in defaults are set on the object and updated in the database.
+// Otherwise a new object is created with the values of both the lookup and the defaults,
+// lookups which are not a plain field name (I.E. `Email__iexact`) are not set on the new object.
+//
+// It returns the object and a boolean indicating whether the object was created.
+//
+// Like [QuerySet.GetOrCreate], the row is locked with `SELECT ... FOR UPDATE` if the database supports it,
+// and the row is updated instead if it was created concurrently by another transaction.
+//
+// It panics if the lookup is empty.
+func (qs *QuerySet[T]) UpdateOrCreate(lookup map[string]any, defaults map[string]any) (T, bool, error) {
+	if len(lookup) == 0 {
+		panic(query_errors.ErrNoWhereClause)
+	}
+
+	var obj, created, err = qs.updateOrCreate(lookup, defaults)
+	if err != nil && drivers.IsUniqueViolation(err) {
+		// The object was created by another transaction after
+		// it was not found, it should be updated instead.
+		return qs.updateOrCreate(lookup, defaults)
+	}
+
+	return obj, created, err
+}
+
+func (qs *QuerySet[T]) updateOrCreate(lookup map[string]any, defaults map[string]any) (T, bool, error) {
+	var tx, err = qs.GetOrCreateTransaction()
+	if err != nil {
+		return *new(T), false, errors.Wrapf(
+			err, "failed to get transaction for %T", qs.internals.Model.Object,
+		)
+	}
+	defer tx.Rollback()
+
+	// sorted for a deterministic order of the updated fields
+	var names = slices.Sorted(maps.Keys(defaults))
+
+	var nqs = qs.Filter(lookup)
+	nqs.internals.ForUpdate = true
+	nqs.useCache = false
+	row, err := nqs.Get()
+	qs.latestQuery = nqs.latestQuery
+	switch {
+	case err == nil:
+		var (
+			obj     = row.Object
+			defs    = obj.FieldDefs()
+			primary = defs.Primary()
+			fields  = make([]any, 0, len(names))
+		)
+
+		if primary == nil {
+			return *new(T), false, errors.Wrapf(
+				query_errors.ErrNoUniqueKey,
+				"cannot update %T without a primary key", obj,
+			)
+		}
+
+		for _, name := range names {
+			if err := defs.Set(name, defaults[name]); err != nil {
+				return *new(T), false, errors.Wrapf(
+					err, "failed to set field %q on %T", name, obj,
+				)
+			}
+			fields = append(fields, name)
+		}
+
+		if len(fields) > 0 {
+			var uqs = qs.Clone()
+			uqs.internals.Where = nil
+			_, err = uqs.
+				Select(fields...).
+				Filter(primary.Name(), primary.GetValue()).
+				Update(obj)
+			if err != nil {
+				return *new(T), false, errors.Wrapf(
+					err, "failed to update object %T", obj,
+				)
+			}
+		}
+
+		return obj, false, tx.Commit()
+
+	case errors.Is(err, query_errors.ErrNoRows):
+		var (
+			obj  = internal.NewObjectFromIface(qs.internals.Model.Object).(T)
+			defs = obj.FieldDefs()
+		)
+
+		for name, value := range lookup {
+			if strings.Contains(name, "__") || strings.Contains(name, ".") {
+				continue
+			}
+
+			if err := defs.Set(name, value); err != nil {
+				return *new(T), false, errors.Wrapf(
+					err, "failed to set field %q on %T", name, obj,
+				)
+			}
+		}
+
+		for _, name := range names {
+			if err := defs.Set(name, defaults[name]); err != nil {
+				return *new(T), false, errors.Wrapf(
+					err, "failed to set field %q on %T", name, obj,
+				)
+			}
+		}
+
+		obj, err = qs.createInSavepoint(obj)
+		if err != nil {
+			return *new(T), false, errors.Wrapf(
+				err, "failed to create object %T", qs.internals.Model.Object,
+			)
+		}
+
+		return obj, true, tx.Commit()
+
+	default:
+		return *new(T), false, errors.Wrapf(
+			err, "failed to get object %T", qs.internals.Model.Object,
+		)
+	}
+}
+
+// createInSavepoint creates the object in a savepoint if the queryset is in a postgres transaction.
+//
+// A unique constraint violation aborts the whole transaction on postgres,
+// rolling back to the savepoint allows the transaction to retrieve the conflicting row afterwards.
+// Other databases only roll back the failed statement, the savepoint would only cost extra queries.
+func (qs *QuerySet[T]) createInSavepoint(value T) (T, error) {
+	var inf = qs.compiler.ExpressionInfo(ChangeObjectsType[T, attrs.Definer](qs), qs.internals)
+	if _, ok := inf.Driver.(*drivers.DriverPostgres); !ok || !qs.compiler.InTransaction() {
+		return qs.Create(value)
+	}
+
+	var savepoint, err = newSavepointTransaction(qs.context, qs.compiler.Transaction())
+	if err != nil {
+		return *new(T), err
+	}
+
+	obj, err := qs.Create(value)
+	if err != nil {
+		if rollbackErr := savepoint.Rollback(); rollbackErr != nil {
+			return *new(T), errors.Wrapf(err, "%v", rollbackErr)
+		}
+		return *new(T), err
+	}
+
+	return obj, savepoint.Commit()
 }
 
 // First is used to retrieve the first row from the database.
@@ -2114,10 +2486,17 @@ func (qs *QuerySet[T]) Exists() (bool, error) {
 	)
 	qs.latestQuery = resultQuery
 
+	var cacheKey = qs.cacheKey("Exists", resultQuery)
+	if cached, ok := cacheGet[bool](qs, cacheKey); ok {
+		return cached, nil
+	}
+
 	var exists, err = resultQuery.Exec()
 	if err != nil {
 		return false, err
 	}
+
+	qs.cacheSet(cacheKey, exists > 0)
 	return exists > 0, nil
 }
 
@@ -2126,10 +2505,17 @@ func (qs *QuerySet[T]) Exists() (bool, error) {
 // It returns a CountQuery that can be executed to get the result, which is an int64 indicating the number of rows.
 func (qs *QuerySet[T]) Count() (int64, error) {
 	var q = qs.queryCount()
+	var cacheKey = qs.cacheKey("Count", q)
+	if cached, ok := cacheGet[int64](qs, cacheKey); ok {
+		return cached, nil
+	}
+
 	var count, err = q.Exec()
 	if err != nil {
 		return 0, err
 	}
+
+	qs.cacheSet(cacheKey, count)
 	return count, nil
 }
 
@@ -2269,6 +2655,12 @@ func (qs *QuerySet[T]) Update(value T, expressions ...any) (int64, error) {
 // It takes a list of definer objects as arguments and returns a Query that can be executed
 // to get the result, which is a slice of the created objects.
 func (qs *QuerySet[T]) BulkCreate(objects []T) ([]T, error) {
+	return qs.bulkCreate(objects, nil)
+}
+
+// bulkCreate inserts the objects, if a conflict clause is provided
+// the rows which conflict with an existing row are updated instead.
+func (qs *QuerySet[T]) bulkCreate(objects []T, conflict *OnConflict) ([]T, error) {
 	var tx, err = qs.GetOrCreateTransaction()
 	if err != nil {
 		return nil, errors.Wrapf(
@@ -2277,6 +2669,10 @@ func (qs *QuerySet[T]) BulkCreate(objects []T) ([]T, error) {
 	}
 	defer tx.Rollback()
 
+	// invalidate the cached results after the transaction is committed,
+	// or after the changes are made if the queryset is not in a transaction
+	defer invalidateCacheOnCommit(qs.compiler.Transaction(), qs.internals.Model.TableName)()
+
 	var (
 		infos   = make([]UpdateInfo, 0, len(objects))
 		primary attrs.Field
@@ -2359,11 +2755,18 @@ func (qs *QuerySet[T]) BulkCreate(objects []T) ([]T, error) {
 		infos = append(infos, info)
 	}
 
+	var internals = qs.internals
+	if conflict != nil {
+		var dereferenced = *qs.internals
+		dereferenced.OnConflict = conflict
+		internals = &dereferenced
+	}
+
 	var support = qs.compiler.SupportsReturning()
 	var resultQuery = qs.compiler.BuildCreateQuery(
 		qs.context,
 		ChangeObjectsType[T, attrs.Definer](qs),
-		qs.internals,
+		internals,
 		infos,
 	)
 	qs.latestQuery = resultQuery
@@ -2537,6 +2940,8 @@ func (qs *QuerySet[T]) BulkCreate(objects []T) ([]T, error) {
 //
 // It takes a list of definer objects as arguments and any possible NamedExpressions.
 // It does not try to call any save methods on the objects.
+//
+// Fields which were deferred when the object was loaded are not updated, see [DeferredFieldsGetter].
 func (qs *QuerySet[T]) BulkUpdate(objects []T, expressions ...any) (int64, error) {
 
 	var tx, err = qs.GetOrCreateTransaction()
@@ -2547,6 +2952,10 @@ func (qs *QuerySet[T]) BulkUpdate(objects []T, expressions ...any) (int64, error
 	}
 	defer tx.Rollback()
 
+	// invalidate the cached results after the transaction is committed,
+	// or after the changes are made if the queryset is not in a transaction
+	defer invalidateCacheOnCommit(qs.compiler.Transaction(), qs.internals.Model.TableName)()
+
 	var exprMap = make(map[string]expr.NamedExpression, len(expressions))
 	for _, expression := range expressions {
 		switch e := expression.(type) {
@@ -2628,6 +3037,13 @@ func (qs *QuerySet[T]) BulkUpdate(objects []T, expressions ...any) (int64, error
 			}
 		}
 
+		// deferred fields were not loaded from the database and hold
+		// their zero value, unless they were changed since
+		var deferred []string
+		if getter, ok := any(obj).(DeferredFieldsGetter); ok {
+			deferred = getter.DeferredFields()
+		}
+
 		var defs, fields = qs.updateFields(obj)
 		var info = UpdateInfo{
 			FieldInfo: FieldInfo[attrs.Field]{
@@ -2661,6 +3077,10 @@ func (qs *QuerySet[T]) BulkUpdate(objects []T, expressions ...any) (int64, error
 				continue
 			}
 
+			if slices.Contains(deferred, fieldName) {
+				continue
+			}
+
 			var value, err = field.Value()
 			if err != nil {
 				panic(fmt.Errorf("failed to get value for field %q: %w", field.Name(), err))
@@ -2829,6 +3249,10 @@ func (qs *QuerySet[T]) Delete(objects ...T) (int64, error) {
 	}
 	defer tx.Rollback()
 
+	// invalidate the cached results after the transaction is committed,
+	// or after the changes are made if the queryset is not in a transaction
+	defer invalidateCacheOnCommit(qs.compiler.Transaction(), qs.internals.Model.TableName)()
+
 	if len(objects) > 0 {
 		var where, err = GenerateObjectsWhereClause(objects...)
 		if err != nil {
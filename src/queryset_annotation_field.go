@@ -63,6 +63,11 @@ func (q *queryField[T]) AllowBlank() bool      { return true }
 func (q *queryField[T]) AllowEdit() bool       { return false }
 func (q *queryField[T]) GetValue() any         { return q.value }
 func (q *queryField[T]) SetValue(v any, _ bool) error {
+	if v == nil {
+		// NULL, I.E. a subquery which did not return any rows
+		q.value = *new(T)
+		return nil
+	}
 	val, ok := v.(T)
 	if !ok {
 		return fmt.Errorf("type mismatch on queryField[%T]: %v", *new(T), v)
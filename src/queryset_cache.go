@@ -0,0 +1,319 @@
+package queries
+
+import (
+	"container/list"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/Nigel2392/go-signals"
+)
+
+// DEFAULT_CACHE_SIZE is the maximum number of results stored by the [DefaultCacheBackend].
+const DEFAULT_CACHE_SIZE = 1024
+
+// DefaultCacheBackend is the backend used by [QuerySet.Cache] when no backend is provided.
+var DefaultCacheBackend CacheBackend = NewLRUCache(DEFAULT_CACHE_SIZE)
+
+// A CacheBackend stores the results of querysets for which caching was enabled with [QuerySet.Cache].
+//
+// The results are stored together with the tables which the query reads from,
+// the backend must remove them when one of these tables is invalidated.
+//
+// It must be safe for concurrent use.
+type CacheBackend interface {
+	// Get returns the result stored for the key, if it exists and has not expired.
+	Get(key string) (value any, ok bool)
+
+	// Set stores the result for the key.
+	//
+	// The result should expire after the ttl, a ttl of zero means the result does not expire.
+	Set(key string, value any, tables []string, ttl time.Duration)
+
+	// Invalidate removes all results which were read from the given table.
+	Invalidate(table string)
+
+	// Clear removes all results.
+	Clear()
+}
+
+// queryCache holds the cache settings of a queryset.
+type queryCache struct {
+	backend CacheBackend
+	ttl     time.Duration
+}
+
+// cacheBackends holds all backends which have been used by a queryset,
+// these are invalidated when a model is saved or deleted.
+var cacheBackends sync.Map // map[CacheBackend]struct{}
+
+var _, _ = SignalPostModelSave.Listen(func(s signals.Signal[SignalSave], ss SignalSave) error {
+	var tx drivers.Transaction
+	if ss.Using != nil {
+		tx = ss.Using.Transaction()
+	}
+	invalidateCacheOnCommit(tx, ss.Instance.FieldDefs().TableName())()
+	return nil
+})
+
+var _, _ = SignalPostModelDelete.Listen(func(s signals.Signal[attrs.Definer], obj attrs.Definer) error {
+	// the delete signal is only sent by [DeleteObject],
+	// which does not run in a transaction.
+	invalidateCacheOnCommit(nil, obj.FieldDefs().TableName())()
+	return nil
+})
+
+// invalidateCacheOnCommit invalidates the cached results of the tables once the changes made to them are visible to other connections.
+//
+// If tx is a transaction, the cache is invalidated after it is committed - invalidating it earlier would allow
+// a concurrent query to cache the rows from before the commit again. The cache is also invalidated when the
+// transaction is rolled back, queries inside of the transaction could have cached the rolled back rows.
+//
+// If tx is nil the changes are visible immediately, the returned function invalidates the cache
+// and should be called after the changes are made.
+func invalidateCacheOnCommit(tx drivers.Transaction, tables ...string) (invalidate func()) {
+	invalidate = func() { InvalidateCache(tables...) }
+	if hooked, ok := tx.(hookedTransaction); ok {
+		hooked.transactionHooks().add(invalidate, invalidate)
+		return func() {}
+	}
+	return invalidate
+}
+
+// InvalidateCache removes all cached results which were read from the given tables
+// from the backends which have been used by a queryset.
+//
+// The cache is invalidated automatically when a model is saved or deleted, or when a queryset
+// writes to the table. It should be called when the table is changed in any other way, I.E. with raw SQL.
+func InvalidateCache(tables ...string) {
+	cacheBackends.Range(func(key, _ any) bool {
+		var backend = key.(CacheBackend)
+		for _, table := range tables {
+			backend.Invalidate(table)
+		}
+		return true
+	})
+}
+
+// Cache enables caching of the results of the queryset in the given backend,
+// if the backend is nil the [DefaultCacheBackend] is used.
+//
+// The results are keyed by the compiled SQL and arguments of the query, as returned by [QuerySet.LatestQuery].
+// They expire after the ttl, a ttl of zero means the results are stored until they are invalidated.
+//
+// The results of [QuerySet.All], [QuerySet.Get], [QuerySet.Values], [QuerySet.ValuesList],
+// [QuerySet.Aggregate], [QuerySet.Count] and [QuerySet.Exists] are cached.
+// It can be used like so:
+//
+//	var rows, err = queries.GetQuerySet(&Post{}).
+//		Filter("Published", true).
+//		Cache(nil, time.Minute).
+//		All()
+//
+// Cached results are invalidated per table when a model is saved or deleted, or when a queryset writes to the table.
+// Tables which are only used in subqueries or common table expressions are not tracked.
+//
+// Queries executed inside of a transaction are neither read from nor stored in the cache.
+//
+// The cached objects are shared between the querysets which read them, they should not be modified.
+func (qs *QuerySet[T]) Cache(backend CacheBackend, ttl time.Duration) *QuerySet[T] {
+	if backend == nil {
+		backend = DefaultCacheBackend
+	}
+
+	cacheBackends.LoadOrStore(backend, struct{}{})
+
+	var nqs = qs.Clone()
+	nqs.cache = &queryCache{
+		backend: backend,
+		ttl:     ttl,
+	}
+	return nqs
+}
+
+// cacheKey returns the key of the query in the cache backend of the queryset.
+//
+// It returns an empty string if the queryset has no cache or if it is executed inside of a transaction.
+func (qs *QuerySet[T]) cacheKey(method string, query QueryInfo) string {
+	if qs.cache == nil || qs.compiler.InTransaction() {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(method)
+	sb.WriteString(":")
+	sb.WriteString(qs.compiler.DatabaseName())
+	sb.WriteString(":")
+	sb.WriteString(query.SQL())
+	for _, arg := range query.Args() {
+		fmt.Fprintf(&sb, ":%T(%v)", arg, arg)
+	}
+	return sb.String()
+}
+
+// cacheTables returns the tables which the query of the queryset reads from.
+func (qs *QuerySet[T]) cacheTables() []string {
+	var tables = []string{qs.internals.Model.TableName}
+	for _, join := range qs.internals.Joins {
+		if join.Subquery != nil {
+			tables = append(tables, join.Subquery.cacheTables()...)
+			continue
+		}
+		tables = append(tables, join.Table.Name)
+	}
+	for _, combined := range qs.internals.Combined {
+		tables = append(tables, combined.QuerySet.cacheTables()...)
+	}
+	slices.Sort(tables)
+	return slices.Compact(tables)
+}
+
+func (qs *QuerySet[T]) cacheSet(key string, value any) {
+	if key == "" {
+		return
+	}
+	qs.cache.backend.Set(key, value, qs.cacheTables(), qs.cache.ttl)
+}
+
+// cacheGet returns the cached result of the type V for the key.
+func cacheGet[V any, T attrs.Definer](qs *QuerySet[T], key string) (value V, ok bool) {
+	if key == "" {
+		return value, false
+	}
+
+	cached, ok := qs.cache.backend.Get(key)
+	if !ok {
+		return value, false
+	}
+
+	value, ok = cached.(V)
+	return value, ok
+}
+
+// LRUCache is an in-memory [CacheBackend] which removes the least recently used result
+// when the maximum number of results is reached.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	tables  map[string]map[string]struct{}
+	order   *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   any
+	tables  []string
+	expires time.Time
+}
+
+// NewLRUCache creates a new [LRUCache] which stores at most size results.
+//
+// A size of zero or less means the number of results is not limited.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		tables:  make(map[string]map[string]struct{}),
+		order:   list.New(),
+	}
+}
+
+func (c *LRUCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var elem, ok = c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	var entry = elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(key string, value any, tables []string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	var entry = &lruEntry{
+		key:    key,
+		value:  value,
+		tables: tables,
+	}
+
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for _, table := range tables {
+		var keys, ok = c.tables[table]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tables[table] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRUCache) Invalidate(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.tables[table] {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+	delete(c.tables, table)
+}
+
+func (c *LRUCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.tables = make(map[string]map[string]struct{})
+	c.order.Init()
+}
+
+// Len returns the number of results stored in the cache, including expired results which have not been removed yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove removes the element from the cache, the lock must be held by the caller.
+func (c *LRUCache) remove(elem *list.Element) {
+	var entry = elem.Value.(*lruEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	for _, table := range entry.tables {
+		if keys, ok := c.tables[table]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(c.tables, table)
+			}
+		}
+	}
+}
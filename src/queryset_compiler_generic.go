@@ -6,7 +6,10 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/Nigel2392/go-django-queries/internal"
@@ -52,6 +55,19 @@ func newExpressionInfo(g *genericQueryBuilder, qs *QuerySet[attrs.Definer], i *Q
 		panic(fmt.Errorf("unknown database driver: %s", dbName))
 	}
 
+	var tableAlias = subqueryTableAlias(i)
+	var formatField = g.FormatColumn
+	if tableAlias != "" {
+		formatField = func(col *expr.TableColumn) (string, []any) {
+			if col.TableOrAlias != i.Model.TableName {
+				return g.FormatColumn(col)
+			}
+			var aliased = *col
+			aliased.TableOrAlias = tableAlias
+			return g.FormatColumn(&aliased)
+		}
+	}
+
 	return &expr.ExpressionInfo{
 		Driver: g.driver,
 		Model: attrs.NewObject[attrs.Definer](
@@ -60,7 +76,7 @@ func newExpressionInfo(g *genericQueryBuilder, qs *QuerySet[attrs.Definer], i *Q
 		Quote:           g.QuoteString,
 		QuoteIdentifier: g.QuoteIdentifier,
 		AliasGen:        qs.AliasGen,
-		FormatField:     g.FormatColumn,
+		FormatField:     formatField,
 		Placeholder:     generic_PLACEHOLDER,
 		Lookups: expr.ExpressionLookupInfo{
 			PrepForLikeQuery: g.PrepForLikeQuery,
@@ -72,7 +88,32 @@ func newExpressionInfo(g *genericQueryBuilder, qs *QuerySet[attrs.Definer], i *Q
 		ForUpdate:          updating,
 		Annotations:        i.Annotations,
 		SupportsWhereAlias: supportsWhereAlias,
+		Outer:              i.OuterInfo,
+		TableAlias:         tableAlias,
+		ResolveColumn:      i.subqueryColumn,
+	}
+}
+
+// subqueryTableAlias returns the alias for the base table of a subquery
+// which selects from the same table as its outer query.
+//
+// Without the alias the table of the subquery would shadow the table of the outer query,
+// and the columns referenced with [expr.OuterRef] would resolve to the subquery instead.
+func subqueryTableAlias(i *QuerySetInternals) string {
+	if i.OuterInfo == nil || i.OuterInfo.Model == nil {
+		return ""
+	}
+
+	var outerTable = i.OuterInfo.TableAlias
+	if outerTable == "" {
+		outerTable = i.OuterInfo.Model.FieldDefs().TableName()
+	}
+
+	if outerTable != i.Model.TableName {
+		return ""
 	}
+
+	return "U0"
 }
 
 const generic_PLACEHOLDER = "?"
@@ -406,14 +447,14 @@ func (g *genericQueryBuilder) Transaction() drivers.Transaction {
 	return nil
 }
 
-func (g *genericQueryBuilder) StartTransaction(ctx context.Context) (drivers.Transaction, error) {
+func (g *genericQueryBuilder) StartTransaction(ctx context.Context, opts *drivers.TxOptions) (drivers.Transaction, error) {
 	if g.InTransaction() {
 		return g.transaction, nil
 	}
 
-	var tx, err = g.queryInfo.DB.Begin(ctx)
+	var tx, err = g.queryInfo.DB.Begin(ctx, opts)
 	if err != nil {
-		return nil, query_errors.ErrFailedStartTransaction
+		return nil, fmt.Errorf("%w: %w", query_errors.ErrFailedStartTransaction, err)
 	}
 
 	// logger.Debugf("Starting transaction for %s", g.DatabaseName())
@@ -430,7 +471,7 @@ func (g *genericQueryBuilder) WithTransaction(t drivers.Transaction) (drivers.Tr
 		return nil, query_errors.ErrTransactionNil
 	}
 
-	g.transaction = &wrappedTransaction{t, g}
+	g.transaction = newWrappedTransaction(t, g)
 	return g.transaction, nil
 }
 
@@ -463,37 +504,34 @@ func (g *genericQueryBuilder) BuildSelectQuery(
 ) CompiledQuery[[][]interface{}] {
 	var (
 		query = new(strings.Builder)
-		args  []any
 		inf   = newExpressionInfo(g, qs, internals, false)
 	)
 
-	query.WriteString("SELECT ")
-
-	if internals.Distinct {
-		query.WriteString("DISTINCT ")
+	var args, err = g.writeWith(query, internals)
+	if err != nil {
+		return errorQuery[[][]interface{}](g, inf.Model, err)
 	}
 
-	for i, info := range internals.Fields {
-		if i > 0 {
-			query.WriteString(", ")
-		}
+	if err := g.checkDistinctOn(internals); err != nil {
+		return errorQuery[[][]interface{}](g, inf.Model, err)
+	}
 
-		args = append(
-			args, info.WriteFields(
-				query, inf)...)
+	if err := g.checkJoins(internals); err != nil {
+		return errorQuery[[][]interface{}](g, inf.Model, err)
 	}
 
-	query.WriteString(" FROM ")
-	g.writeTableName(query, internals)
-	args = append(args, g.writeJoins(query, inf, internals.Joins)...)
-	args = append(args, g.writeWhereClause(query, inf, internals.Where)...)
-	args = append(args, g.writeGroupBy(query, inf, internals.GroupBy)...)
-	args = append(args, g.writeHaving(query, inf, internals.Having)...)
-	g.writeOrderBy(query, internals.OrderBy)
+	selectArgs, err := g.writeSelect(query, inf, internals)
+	if err != nil {
+		return errorQuery[[][]interface{}](g, inf.Model, err)
+	}
+	args = append(args, selectArgs...)
+	g.writeOrderBy(query, inf, internals.OrderBy)
 	args = append(args, g.writeLimitOffset(query, internals.Limit, internals.Offset)...)
 
 	if internals.ForUpdate {
-		query.WriteString(" FOR UPDATE")
+		if err := g.writeForUpdate(query, inf, internals.ForUpdateOptions); err != nil {
+			return errorQuery[[][]interface{}](g, inf.Model, err)
+		}
 	}
 
 	return &QueryObject[[][]interface{}]{
@@ -503,49 +541,111 @@ func (g *genericQueryBuilder) BuildSelectQuery(
 			Params:  args,
 			Builder: g,
 		},
-		Execute: func(sql string, args ...any) ([][]interface{}, error) {
+		Execute: g.executeSelect(ctx, internals.Fields),
+	}
+}
 
-			rows, err := g.DB().QueryContext(ctx, sql, args...)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to execute query")
-			}
+func (g *genericQueryBuilder) BuildCompoundQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+) CompiledQuery[[][]interface{}] {
+	var (
+		query = new(strings.Builder)
+		inf   = newExpressionInfo(g, qs, internals, false)
+	)
 
-			defer rows.Close()
+	var args, err = g.writeWith(query, internals)
+	if err != nil {
+		return errorQuery[[][]interface{}](g, inf.Model, err)
+	}
 
-			if err := rows.Err(); err != nil {
-				return nil, errors.Wrap(err, "failed to iterate rows")
-			}
+	compoundArgs, err := g.writeCompound(query, qs, internals)
+	if err != nil {
+		return errorQuery[[][]interface{}](g, inf.Model, err)
+	}
+	args = append(args, compoundArgs...)
 
-			var results = make([][]interface{}, 0, 8)
-			var amountCols = 0
-			for _, info := range internals.Fields {
-				if info.Through != nil {
-					amountCols += len(info.Through.Fields)
-				}
-				amountCols += len(info.Fields)
+	// The columns of a compound select cannot be referenced by their table,
+	// the ordering is written by the position of the column in the result set.
+	if len(internals.OrderBy) > 0 {
+		query.WriteString(" ORDER BY ")
+
+		for i, ord := range internals.OrderBy {
+			if i > 0 {
+				query.WriteString(", ")
 			}
 
-			for rows.Next() {
-				var row = make([]interface{}, amountCols)
-				for i := range row {
-					row[i] = new(interface{})
-				}
-				err = rows.Scan(row...)
-				if err != nil {
-					return nil, errors.Wrap(err, "failed to scan row")
-				}
+			var pos, ok = compoundColumnPosition(inf, internals.Fields, ord.Column)
+			if !ok {
+				var col = new(strings.Builder)
+				writeCol(col, &ord.Column)
+				return errorQuery[[][]interface{}](g, inf.Model, fmt.Errorf(
+					"cannot order compound query by %q, column is not selected: %w",
+					col.String(), query_errors.ErrFieldNotFound,
+				))
+			}
 
-				var result = make([]interface{}, amountCols)
-				for i, iface := range row {
-					var field = iface.(*interface{})
-					result[i] = *field
-				}
+			query.WriteString(strconv.Itoa(pos))
 
-				results = append(results, result)
+			if ord.Desc {
+				query.WriteString(" DESC")
+			} else {
+				query.WriteString(" ASC")
 			}
+		}
+	}
+
+	args = append(args, g.writeLimitOffset(query, internals.Limit, internals.Offset)...)
 
-			return results, nil
+	return &QueryObject[[][]interface{}]{
+		QueryInformation: QueryInformation{
+			Stmt:    g.queryInfo.DBX(query.String()),
+			Object:  inf.Model,
+			Params:  args,
+			Builder: g,
 		},
+		Execute: g.executeSelect(ctx, internals.Fields),
+	}
+}
+
+func (g *genericQueryBuilder) executeSelect(ctx context.Context, fields []*FieldInfo[attrs.FieldDefinition]) func(sql string, args ...any) ([][]interface{}, error) {
+	return func(sql string, args ...any) ([][]interface{}, error) {
+		rows, err := g.DB().QueryContext(ctx, sql, args...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to execute query")
+		}
+
+		defer rows.Close()
+
+		var results = make([][]interface{}, 0, 8)
+		var amountCols = countColumns(fields)
+		for rows.Next() {
+			var row = make([]interface{}, amountCols)
+			for i := range row {
+				row[i] = new(interface{})
+			}
+			err = rows.Scan(row...)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to scan row")
+			}
+
+			var result = make([]interface{}, amountCols)
+			for i, iface := range row {
+				var field = iface.(*interface{})
+				result[i] = *field
+			}
+
+			results = append(results, result)
+		}
+
+		// errors which occur while stepping through the rows,
+		// I.E. in a function called by the query, are only reported here.
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to iterate rows")
+		}
+
+		return results, nil
 	}
 }
 
@@ -556,14 +656,54 @@ func (g *genericQueryBuilder) BuildCountQuery(
 ) CompiledQuery[int64] {
 	var inf = newExpressionInfo(g, qs, internals, false)
 	var query = new(strings.Builder)
-	var args = make([]any, 0)
-	query.WriteString("SELECT COUNT(*) FROM ")
-	g.writeTableName(query, internals)
+	var args, err = g.writeWith(query, internals)
+	if err != nil {
+		return errorQuery[int64](g, inf.Model, err)
+	}
 
-	args = append(args, g.writeJoins(query, inf, internals.Joins)...)
-	args = append(args, g.writeWhereClause(query, inf, internals.Where)...)
-	args = append(args, g.writeGroupBy(query, inf, internals.GroupBy)...)
-	args = append(args, g.writeLimitOffset(query, internals.Limit, internals.Offset)...)
+	if err := g.checkJoins(internals); err != nil {
+		return errorQuery[int64](g, inf.Model, err)
+	}
+
+	// Count the rows of the combined result set,
+	// ordering and limits do not apply to the count.
+	if len(internals.Combined) > 0 {
+		query.WriteString("SELECT COUNT(*) FROM (")
+		var compoundArgs, err = g.writeCompound(query, qs, internals)
+		if err != nil {
+			return errorQuery[int64](g, inf.Model, err)
+		}
+		query.WriteString(") AS ")
+		query.WriteString(g.quote)
+		query.WriteString("compound_count")
+		query.WriteString(g.quote)
+		args = append(args, compoundArgs...)
+	} else if len(internals.DistinctOn) > 0 {
+		if err := g.checkDistinctOn(internals); err != nil {
+			return errorQuery[int64](g, inf.Model, err)
+		}
+
+		// Count the rows which are left after the
+		// duplicate rows of each group are removed.
+		var countArgs, err = g.writeDistinctOnCount(query, inf, internals)
+		if err != nil {
+			return errorQuery[int64](g, inf.Model, err)
+		}
+		args = append(args, countArgs...)
+		args = append(args, g.writeLimitOffset(query, internals.Limit, internals.Offset)...)
+	} else {
+		query.WriteString("SELECT COUNT(*) FROM ")
+		g.writeTableName(query, inf, internals)
+
+		var joinArgs, err = g.writeJoins(query, inf, internals.Joins)
+		if err != nil {
+			return errorQuery[int64](g, inf.Model, err)
+		}
+		args = append(args, joinArgs...)
+		args = append(args, g.writeWhereClause(query, inf, internals.Where)...)
+		args = append(args, g.writeGroupBy(query, inf, internals.GroupBy)...)
+		args = append(args, g.writeLimitOffset(query, internals.Limit, internals.Offset)...)
+	}
 
 	return &QueryObject[int64]{
 		QueryInformation: QueryInformation{
@@ -587,6 +727,152 @@ func (g *genericQueryBuilder) BuildCountQuery(
 	}
 }
 
+// SQLite only supports `EXPLAIN QUERY PLAN`, the rows
+// returned form a tree which is rendered like the SQLite shell does.
+func (g *genericQueryBuilder) BuildExplainQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+	options ExplainOptions,
+) CompiledQuery[*QueryPlan] {
+	if options.Analyze {
+		return errorQuery[*QueryPlan](g, qs.Model(), fmt.Errorf(
+			"EXPLAIN ANALYZE is not supported by SQLite: %w",
+			query_errors.ErrNotImplemented,
+		))
+	}
+
+	var query, err = g.explainSelect(ctx, qs, internals)
+	if err != nil {
+		return errorQuery[*QueryPlan](g, qs.Model(), err)
+	}
+
+	return &QueryObject[*QueryPlan]{
+		QueryInformation: QueryInformation{
+			Builder: g,
+			Stmt:    "EXPLAIN QUERY PLAN " + query.SQL(),
+			Params:  query.Args(),
+			Object:  query.Model(),
+		},
+		Execute: func(sql string, args ...any) (*QueryPlan, error) {
+			rows, err := g.DB().QueryContext(ctx, sql, args...)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to execute query")
+			}
+			defer rows.Close()
+
+			var root = &PlanNode{
+				Detail:     "QUERY PLAN",
+				Properties: make(map[string]any),
+			}
+
+			var nodes = map[int64]*PlanNode{0: root}
+			for rows.Next() {
+				var (
+					id, parent, notUsed int64
+					detail              string
+				)
+
+				if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+					return nil, errors.Wrap(err, "failed to scan row")
+				}
+
+				var node = &PlanNode{
+					Detail:     detail,
+					Properties: make(map[string]any),
+				}
+
+				var parentNode, ok = nodes[parent]
+				if !ok {
+					parentNode = root
+				}
+
+				parentNode.Children = append(parentNode.Children, node)
+				nodes[id] = node
+			}
+
+			if err := rows.Err(); err != nil {
+				return nil, errors.Wrap(err, "failed to iterate rows")
+			}
+
+			return &QueryPlan{
+				Output: root.String(),
+				Root:   root,
+			}, nil
+		},
+	}
+}
+
+// explainSelect builds the select query to be explained,
+// compound queries are explained as a whole.
+func (g *genericQueryBuilder) explainSelect(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+) (CompiledQuery[[][]interface{}], error) {
+	var query CompiledQuery[[][]interface{}]
+	if len(internals.Combined) > 0 {
+		query = g.BuildCompoundQuery(ctx, qs, internals)
+	} else {
+		query = g.BuildSelectQuery(ctx, qs, internals)
+	}
+
+	if query.SQL() == "" {
+		// the query failed to compile,
+		// executing it returns the error
+		var _, err = query.Exec()
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// explainQuery wraps the select query in the given EXPLAIN statement,
+// the output is parsed with the parse function if it is not nil.
+func (g *genericQueryBuilder) explainQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+	explain string,
+	parse func(output string) (*PlanNode, error),
+) CompiledQuery[*QueryPlan] {
+	var query, err = g.explainSelect(ctx, qs, internals)
+	if err != nil {
+		return errorQuery[*QueryPlan](g, qs.Model(), err)
+	}
+
+	return &QueryObject[*QueryPlan]{
+		QueryInformation: QueryInformation{
+			Builder: g,
+			Stmt:    explain + " " + query.SQL(),
+			Params:  query.Args(),
+			Object:  query.Model(),
+		},
+		Execute: func(sql string, args ...any) (*QueryPlan, error) {
+			rows, err := g.DB().QueryContext(ctx, sql, args...)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to execute query")
+			}
+			defer rows.Close()
+
+			var plan = &QueryPlan{}
+			plan.Output, err = scanExplainRows(rows)
+			if err != nil {
+				return nil, err
+			}
+
+			if parse != nil {
+				plan.Root, err = parse(plan.Output)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to parse query plan")
+				}
+			}
+
+			return plan, nil
+		},
+	}
+}
+
 func (g *genericQueryBuilder) BuildCreateQuery(
 	ctx context.Context,
 	qs *GenericQuerySet,
@@ -656,6 +942,8 @@ func (g *genericQueryBuilder) BuildCreateQuery(
 		written = true
 	}
 
+	g.writeOnConflict(query, internals)
+
 	switch {
 	case support == drivers.SupportsReturningLastInsertId:
 
@@ -736,12 +1024,15 @@ func (g *genericQueryBuilder) BuildCreateQuery(
 						return nil, errors.Wrap(err, "failed to scan row")
 					}
 
-					if err := rows.Err(); err != nil {
-						return nil, errors.Wrap(err, "failed to iterate rows")
-					}
-
 					result = append(result, []interface{}{*id})
 				}
+
+				// errors such as constraint violations can
+				// be returned while iterating over the rows
+				if err := rows.Err(); err != nil {
+					return nil, errors.Wrap(err, "failed to iterate rows")
+				}
+
 				return result, nil
 
 			case drivers.SupportsReturningColumns:
@@ -763,10 +1054,6 @@ func (g *genericQueryBuilder) BuildCreateQuery(
 						return nil, errors.Wrap(err, "failed to scan row")
 					}
 
-					if err := rows.Err(); err != nil {
-						return nil, errors.Wrap(err, "failed to iterate rows")
-					}
-
 					for i, iface := range result {
 						var field = iface.(*interface{})
 						result[i] = *field
@@ -775,6 +1062,12 @@ func (g *genericQueryBuilder) BuildCreateQuery(
 					results = append(results, result)
 				}
 
+				// errors such as constraint violations can
+				// be returned while iterating over the rows
+				if err := rows.Err(); err != nil {
+					return nil, errors.Wrap(err, "failed to iterate rows")
+				}
+
 				return results, nil
 
 			case drivers.SupportsReturningNone:
@@ -791,6 +1084,64 @@ func (g *genericQueryBuilder) BuildCreateQuery(
 	}
 }
 
+// writeOnConflict writes the conflict clause of an insert query, if any.
+//
+// If no fields to update are provided, the conflicting fields are set to their own values.
+// This does not change the existing row, but makes sure its primary key is still returned.
+func (g *genericQueryBuilder) writeOnConflict(sb *strings.Builder, internals *QuerySetInternals) {
+	var conflict = internals.OnConflict
+	if conflict == nil {
+		return
+	}
+
+	var update = conflict.Update
+	if len(update) == 0 {
+		update = conflict.Fields
+	}
+
+	switch g.driver.(type) {
+	case *drivers.DriverMySQL, *drivers.DriverMariaDB, drivers.DriverMariaDB:
+		sb.WriteString(" ON DUPLICATE KEY UPDATE ")
+
+		// MySQL does not support RETURNING, setting LAST_INSERT_ID
+		// makes sure the id of an updated row is returned instead.
+		var _, isMySQL = g.driver.(*drivers.DriverMySQL)
+		var primary = internals.Model.Primary
+		if isMySQL && primary != nil {
+			if _, ok := availableForLastInsertId[primary.Type().Kind()]; ok {
+				var col = g.QuoteIdentifier(primary.ColumnName())
+				fmt.Fprintf(sb, "%s = LAST_INSERT_ID(%s), ", col, col)
+			}
+		}
+
+		for i, field := range update {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			var col = g.QuoteIdentifier(field.ColumnName())
+			fmt.Fprintf(sb, "%s = VALUES(%s)", col, col)
+		}
+		return
+	}
+
+	sb.WriteString(" ON CONFLICT (")
+	for i, field := range conflict.Fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(g.QuoteIdentifier(field.ColumnName()))
+	}
+	sb.WriteString(") DO UPDATE SET ")
+
+	for i, field := range update {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		var col = g.QuoteIdentifier(field.ColumnName())
+		fmt.Fprintf(sb, "%s = excluded.%s", col, col)
+	}
+}
+
 func (g *genericQueryBuilder) BuildUpdateQuery(
 	ctx context.Context,
 	qs *GenericQuerySet,
@@ -853,10 +1204,11 @@ func (g *genericQueryBuilder) BuildUpdateQuery(
 		// incorrect column formatting in joins and where clauses.
 		inf.ForUpdate = false
 
-		args = append(
-			args,
-			g.writeJoins(query, inf, info.Joins)...,
-		)
+		var joinArgs, err = g.writeJoins(query, inf, info.Joins)
+		if err != nil {
+			return errorQuery[int64](g, inf.Model, err)
+		}
+		args = append(args, joinArgs...)
 
 		args = append(
 			args,
@@ -894,10 +1246,11 @@ func (g *genericQueryBuilder) BuildDeleteQuery(
 	query.WriteString(internals.Model.TableName)
 	query.WriteString(g.quote)
 
-	args = append(
-		args,
-		g.writeJoins(query, inf, internals.Joins)...,
-	)
+	var joinArgs, err = g.writeJoins(query, inf, internals.Joins)
+	if err != nil {
+		return errorQuery[int64](g, inf.Model, err)
+	}
+	args = append(args, joinArgs...)
 
 	args = append(
 		args,
@@ -926,44 +1279,421 @@ func (g *genericQueryBuilder) BuildDeleteQuery(
 	}
 }
 
-func (g *genericQueryBuilder) writeTableName(sb *strings.Builder, internals *QuerySetInternals) {
-	sb.WriteString(g.quote)
-	sb.WriteString(internals.Model.TableName)
-	sb.WriteString(g.quote)
-}
-
-func (g *genericQueryBuilder) writeJoins(sb *strings.Builder, inf *expr.ExpressionInfo, joins []JoinDef) []any {
+// writeSelect writes the SELECT statement up to and including the HAVING clause.
+func (g *genericQueryBuilder) writeSelect(sb *strings.Builder, inf *expr.ExpressionInfo, internals *QuerySetInternals) ([]any, error) {
 	var args = make([]any, 0)
-	for _, join := range joins {
-		sb.WriteString(" ")
-		sb.WriteString(string(join.TypeJoin))
-		sb.WriteString(" ")
-		sb.WriteString(g.quote)
-		sb.WriteString(join.Table.Name)
-		sb.WriteString(g.quote)
 
-		if join.Table.Alias != "" {
-			sb.WriteString(" AS ")
-			sb.WriteString(g.quote)
-			sb.WriteString(join.Table.Alias)
-			sb.WriteString(g.quote)
+	sb.WriteString("SELECT ")
+
+	var _, postgres = g.driver.(*drivers.DriverPostgres)
+	switch {
+	case len(internals.DistinctOn) > 0 && postgres:
+		args = append(args, g.writeDistinctOnColumns(sb, inf, internals.DistinctOn)...)
+	case internals.Distinct:
+		sb.WriteString("DISTINCT ")
+	}
+
+	for i, info := range internals.Fields {
+		if i > 0 {
+			sb.WriteString(", ")
 		}
 
-		sb.WriteString(" ON ")
-		var condition = join.JoinDefCondition
-		for condition != nil {
+		args = append(
+			args, info.WriteFields(
+				sb, inf)...)
+	}
 
-			var col, argsCol = g.FormatColumn(&condition.ConditionA)
-			sb.WriteString(col)
-			args = append(args, argsCol...)
+	sb.WriteString(" FROM ")
+	g.writeTableName(sb, inf, internals)
+	var joinArgs, err = g.writeJoins(sb, inf, internals.Joins)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, joinArgs...)
+	args = append(args, g.writeWhereClause(sb, inf, internals.Where)...)
+	if len(internals.DistinctOn) > 0 && !postgres {
+		var distinctArgs, err = g.writeDistinctOnEmulated(sb, inf, internals)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, distinctArgs...)
+	}
+	args = append(args, g.writeGroupBy(sb, inf, internals.GroupBy)...)
+	args = append(args, g.writeHaving(sb, inf, internals.Having)...)
+	return args, nil
+}
 
-			sb.WriteString(" ")
-			sb.WriteString(string(condition.Operator))
-			sb.WriteString(" ")
+// checkDistinctOn validates the `DISTINCT ON` columns of the query.
+//
+// The leading columns of the ordering must match the `DISTINCT ON` columns,
+// the emulation for databases other than PostgreSQL also requires a primary key and no grouping.
+func (g *genericQueryBuilder) checkDistinctOn(internals *QuerySetInternals) error {
+	if len(internals.DistinctOn) == 0 {
+		return nil
+	}
 
-			col, argsCol = g.FormatColumn(&condition.ConditionB)
-			sb.WriteString(col)
-			args = append(args, argsCol...)
+	if len(internals.OrderBy) > 0 {
+		var distinct = make(map[string]struct{}, len(internals.DistinctOn))
+		for _, col := range internals.DistinctOn {
+			var sql, _ = g.FormatColumn(&col)
+			distinct[sql] = struct{}{}
+		}
+
+		if len(internals.OrderBy) < len(internals.DistinctOn) {
+			return fmt.Errorf(
+				"DISTINCT ON fields of %T must match the leading ORDER BY fields, got %d ORDER BY fields for %d DISTINCT ON fields",
+				internals.Model.Object, len(internals.OrderBy), len(internals.DistinctOn),
+			)
+		}
+
+		for _, ord := range internals.OrderBy[:len(internals.DistinctOn)] {
+			var sql, _ = g.FormatColumn(&ord.Column)
+			if _, ok := distinct[sql]; !ok {
+				return fmt.Errorf(
+					"DISTINCT ON fields of %T must match the leading ORDER BY fields, %s is not a DISTINCT ON field",
+					internals.Model.Object, sql,
+				)
+			}
+		}
+	}
+
+	if _, ok := g.driver.(*drivers.DriverPostgres); ok {
+		return nil
+	}
+
+	if len(internals.GroupBy) > 0 {
+		return fmt.Errorf(
+			"DISTINCT ON cannot be combined with GROUP BY for %T: %w",
+			internals.Model.Object, query_errors.ErrNotImplemented,
+		)
+	}
+
+	if internals.Model.Object.FieldDefs().Primary() == nil {
+		return fmt.Errorf(
+			"DISTINCT ON requires a primary key for %T: %w",
+			internals.Model.Object, query_errors.ErrNoUniqueKey,
+		)
+	}
+
+	return nil
+}
+
+// writeDistinctOnColumns writes the `DISTINCT ON (...)` clause of a PostgreSQL select statement.
+func (g *genericQueryBuilder) writeDistinctOnColumns(sb *strings.Builder, inf *expr.ExpressionInfo, columns []expr.TableColumn) []any {
+	var args = make([]any, 0)
+	sb.WriteString("DISTINCT ON (")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		var sql, a = inf.FormatField(&col)
+		sb.WriteString(sql)
+		args = append(args, a...)
+	}
+	sb.WriteString(") ")
+	return args
+}
+
+// writeDistinctOnCount writes a count query for a query with `DISTINCT ON` columns.
+//
+// PostgreSQL counts the rows of a `SELECT DISTINCT ON (...)` subquery,
+// other databases count the rows which are selected by the `ROW_NUMBER()` emulation.
+func (g *genericQueryBuilder) writeDistinctOnCount(sb *strings.Builder, inf *expr.ExpressionInfo, internals *QuerySetInternals) ([]any, error) {
+	var args = make([]any, 0)
+	if _, ok := g.driver.(*drivers.DriverPostgres); !ok {
+		sb.WriteString("SELECT COUNT(*) FROM ")
+		g.writeTableName(sb, inf, internals)
+		var joinArgs, err = g.writeJoins(sb, inf, internals.Joins)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, joinArgs...)
+		args = append(args, g.writeWhereClause(sb, inf, internals.Where)...)
+		distinctArgs, err := g.writeDistinctOnEmulated(sb, inf, internals)
+		if err != nil {
+			return nil, err
+		}
+		return append(args, distinctArgs...), nil
+	}
+
+	sb.WriteString("SELECT COUNT(*) FROM (SELECT ")
+	args = append(args, g.writeDistinctOnColumns(sb, inf, internals.DistinctOn)...)
+	sb.WriteString("1 FROM ")
+	g.writeTableName(sb, inf, internals)
+	var joinArgs, err = g.writeJoins(sb, inf, internals.Joins)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, joinArgs...)
+	args = append(args, g.writeWhereClause(sb, inf, internals.Where)...)
+	args = append(args, g.writeGroupBy(sb, inf, internals.GroupBy)...)
+	args = append(args, g.writeHaving(sb, inf, internals.Having)...)
+	sb.WriteString(") AS ")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_count")
+	sb.WriteString(g.quote)
+	return args, nil
+}
+
+// writeDistinctOnEmulated emulates `DISTINCT ON` for databases which do not support it.
+//
+// The rows are numbered per distinct group with `ROW_NUMBER()` in the order of the query,
+// only the rows which are numbered first are selected by their primary key:
+//
+//	WHERE ... AND pk IN (
+//		SELECT pk FROM (
+//			SELECT pk, ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...) AS row FROM ... WHERE ...
+//		) WHERE row = 1
+//	)
+func (g *genericQueryBuilder) writeDistinctOnEmulated(sb *strings.Builder, inf *expr.ExpressionInfo, internals *QuerySetInternals) ([]any, error) {
+	var args = make([]any, 0)
+	var pk, pkArgs = inf.FormatField(&expr.TableColumn{
+		TableOrAlias: internals.Model.TableName,
+		FieldColumn:  internals.Model.Object.FieldDefs().Primary(),
+	})
+
+	if len(internals.Where) > 0 {
+		sb.WriteString(" AND ")
+	} else {
+		sb.WriteString(" WHERE ")
+	}
+
+	sb.WriteString(pk)
+	sb.WriteString(" IN (SELECT ")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on")
+	sb.WriteString(g.quote)
+	sb.WriteString(".")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on_pk")
+	sb.WriteString(g.quote)
+	sb.WriteString(" FROM (SELECT ")
+	sb.WriteString(pk)
+	sb.WriteString(" AS ")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on_pk")
+	sb.WriteString(g.quote)
+	args = append(args, pkArgs...)
+	args = append(args, pkArgs...)
+
+	sb.WriteString(", ROW_NUMBER() OVER (PARTITION BY ")
+	for i, col := range internals.DistinctOn {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		var sql, a = inf.FormatField(&col)
+		sb.WriteString(sql)
+		args = append(args, a...)
+	}
+	g.writeOrderBy(sb, inf, internals.OrderBy)
+	sb.WriteString(") AS ")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on_row")
+	sb.WriteString(g.quote)
+
+	sb.WriteString(" FROM ")
+	g.writeTableName(sb, inf, internals)
+	var joinArgs, err = g.writeJoins(sb, inf, internals.Joins)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, joinArgs...)
+	args = append(args, g.writeWhereClause(sb, inf, internals.Where)...)
+
+	sb.WriteString(") AS ")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on")
+	sb.WriteString(g.quote)
+	sb.WriteString(" WHERE ")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on")
+	sb.WriteString(g.quote)
+	sb.WriteString(".")
+	sb.WriteString(g.quote)
+	sb.WriteString("distinct_on_row")
+	sb.WriteString(g.quote)
+	sb.WriteString(" = 1)")
+	return args, nil
+}
+
+// writeWith writes the WITH clause for the common table expressions of the query.
+//
+// CTEs of the CTE querysets are written before the CTE which uses them,
+// each CTE name is only written once.
+//
+// The CTEs of joined subqueries are written as well.
+func (g *genericQueryBuilder) writeWith(sb *strings.Builder, internals *QuerySetInternals) ([]any, error) {
+	var seen = make(map[string]struct{})
+	var ctes = collectCTEs(internals.CTEs, make([]CTE, 0, len(internals.CTEs)), seen)
+
+	// CTEs of subqueries which are joined as a derived table are moved to the outer query
+	for _, join := range internals.Joins {
+		if join.Subquery != nil {
+			ctes = collectCTEs(join.Subquery.internals.CTEs, ctes, seen)
+		}
+	}
+
+	if len(ctes) == 0 {
+		return []any{}, nil
+	}
+
+	var recursive bool
+	for _, cte := range ctes {
+		recursive = recursive || cte.Recursive
+	}
+
+	sb.WriteString("WITH ")
+	if recursive {
+		sb.WriteString("RECURSIVE ")
+	}
+
+	var args = make([]any, 0)
+	for i, cte := range ctes {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(g.quote)
+		sb.WriteString(cte.Name)
+		sb.WriteString(g.quote)
+		sb.WriteString(" AS (")
+
+		var cteInternals = cte.QuerySet.internals
+		if len(cteInternals.Combined) > 0 {
+			var compoundArgs, err = g.writeCompound(sb, cte.QuerySet, cteInternals)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write CTE %q: %w", cte.Name, err)
+			}
+			args = append(args, compoundArgs...)
+		} else {
+			var inf = newExpressionInfo(g, cte.QuerySet, cteInternals, false)
+			var selectArgs, err = g.writeSelect(sb, inf, cteInternals)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write CTE %q: %w", cte.Name, err)
+			}
+			args = append(args, selectArgs...)
+			g.writeOrderBy(sb, inf, cteInternals.OrderBy)
+			args = append(args, g.writeLimitOffset(sb, cteInternals.Limit, cteInternals.Offset)...)
+		}
+
+		sb.WriteString(")")
+	}
+
+	sb.WriteString(" ")
+	return args, nil
+}
+
+// writeCompound writes the select statement of the queryset
+// combined with the querysets in [QuerySetInternals.Combined].
+//
+// The ordering, limit and offset of the combined querysets are not written,
+// these only apply to the outer query.
+func (g *genericQueryBuilder) writeCompound(sb *strings.Builder, qs *GenericQuerySet, internals *QuerySetInternals) ([]any, error) {
+	var (
+		inf     = newExpressionInfo(g, qs, internals, false)
+		numCols = countColumns(internals.Fields)
+	)
+
+	var args, err = g.writeSelect(sb, inf, internals)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, combined := range internals.Combined {
+		var other = combined.QuerySet.internals
+		if len(other.Combined) > 0 {
+			return nil, fmt.Errorf(
+				"cannot nest compound queries, %T was already combined: %w",
+				other.Model.Object, query_errors.ErrNotImplemented,
+			)
+		}
+
+		if n := countColumns(other.Fields); n != numCols {
+			return nil, fmt.Errorf(
+				"cannot %s queries with a different number of columns (%d != %d): %w",
+				combined.Type, numCols, n, query_errors.ErrTypeMismatch,
+			)
+		}
+
+		sb.WriteString(" ")
+		sb.WriteString(string(combined.Type))
+		sb.WriteString(" ")
+
+		var otherInf = newExpressionInfo(g, combined.QuerySet, other, false)
+		var otherArgs, err = g.writeSelect(sb, otherInf, other)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, otherArgs...)
+	}
+
+	return args, nil
+}
+
+func (g *genericQueryBuilder) writeTableName(sb *strings.Builder, inf *expr.ExpressionInfo, internals *QuerySetInternals) {
+	sb.WriteString(g.quote)
+	sb.WriteString(internals.Model.TableName)
+	sb.WriteString(g.quote)
+
+	if inf.TableAlias != "" {
+		sb.WriteString(" AS ")
+		sb.WriteString(g.quote)
+		sb.WriteString(inf.TableAlias)
+		sb.WriteString(g.quote)
+	}
+}
+
+func (g *genericQueryBuilder) writeJoins(sb *strings.Builder, inf *expr.ExpressionInfo, joins []JoinDef) ([]any, error) {
+	var args = make([]any, 0)
+	for _, join := range joins {
+		sb.WriteString(" ")
+		sb.WriteString(string(join.TypeJoin))
+		sb.WriteString(" ")
+
+		if join.Subquery != nil {
+			var subqueryArgs, err = g.writeJoinSubquery(sb, inf, join)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write subquery of join %q: %w", join.Table.Alias, err)
+			}
+			args = append(args, subqueryArgs...)
+		} else {
+			sb.WriteString(g.quote)
+			sb.WriteString(join.Table.Name)
+			sb.WriteString(g.quote)
+		}
+
+		if join.Table.Alias != "" {
+			sb.WriteString(" AS ")
+			sb.WriteString(g.quote)
+			sb.WriteString(join.Table.Alias)
+			sb.WriteString(g.quote)
+		}
+
+		// a lateral subquery is usually correlated in its own where clause
+		if join.JoinDefCondition == nil {
+			if join.TypeJoin != TypeJoinCross {
+				sb.WriteString(" ON 1 = 1")
+			}
+			continue
+		}
+
+		sb.WriteString(" ON ")
+		var condition = join.JoinDefCondition
+		for condition != nil {
+
+			var col, argsCol = inf.FormatField(&condition.ConditionA)
+			sb.WriteString(col)
+			args = append(args, argsCol...)
+
+			sb.WriteString(" ")
+			sb.WriteString(string(condition.Operator))
+			sb.WriteString(" ")
+
+			col, argsCol = inf.FormatField(&condition.ConditionB)
+			sb.WriteString(col)
+			args = append(args, argsCol...)
 
 			if condition.Next != nil {
 				sb.WriteString(" AND ")
@@ -973,7 +1703,66 @@ func (g *genericQueryBuilder) writeJoins(sb *strings.Builder, inf *expr.Expressi
 		}
 	}
 
-	return args
+	return args, nil
+}
+
+// writeJoinSubquery writes the subquery of a join as a derived table, I.E. `LATERAL (SELECT ...)`.
+//
+// A lateral subquery is compiled with the expression info of the outer query,
+// so that it can reference its columns with [expr.OuterRef].
+func (g *genericQueryBuilder) writeJoinSubquery(sb *strings.Builder, inf *expr.ExpressionInfo, join JoinDef) ([]any, error) {
+	var subquery = join.Subquery.Clone()
+	if join.Lateral {
+		sb.WriteString("LATERAL ")
+		subquery.internals.OuterInfo = inf
+	}
+
+	sb.WriteString("(")
+
+	var (
+		args      []any
+		err       error
+		internals = subquery.internals
+	)
+	if len(internals.Combined) > 0 {
+		args, err = g.writeCompound(sb, subquery, internals)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var subInf = newExpressionInfo(g, subquery, internals, false)
+		args, err = g.writeSelect(sb, subInf, internals)
+		if err != nil {
+			return nil, err
+		}
+		g.writeOrderBy(sb, subInf, internals.OrderBy)
+		args = append(args, g.writeLimitOffset(sb, internals.Limit, internals.Offset)...)
+	}
+
+	sb.WriteString(")")
+	return args, nil
+}
+
+// checkJoins validates the joins of the query.
+//
+// Only PostgreSQL and MySQL (8.0.14 or later) support `LATERAL` joins,
+// the MySQL server version is checked when the query is executed.
+func (g *genericQueryBuilder) checkJoins(internals *QuerySetInternals) error {
+	for _, join := range internals.Joins {
+		if !join.Lateral {
+			continue
+		}
+
+		switch g.driver.(type) {
+		case *drivers.DriverPostgres, *drivers.DriverMySQL:
+		default:
+			return fmt.Errorf(
+				"LATERAL join %q is not supported by %T: %w",
+				join.Table.Alias, g.driver, query_errors.ErrNotImplemented,
+			)
+		}
+	}
+	return nil
 }
 
 func (g *genericQueryBuilder) writeWhereClause(sb *strings.Builder, inf *expr.ExpressionInfo, where []expr.ClauseExpression) []any {
@@ -1015,7 +1804,7 @@ func (g *genericQueryBuilder) writeHaving(sb *strings.Builder, inf *expr.Express
 	return args
 }
 
-func (g *genericQueryBuilder) writeOrderBy(sb *strings.Builder, orderBy []OrderBy) {
+func (g *genericQueryBuilder) writeOrderBy(sb *strings.Builder, inf *expr.ExpressionInfo, orderBy []OrderBy) {
 	if len(orderBy) > 0 {
 		sb.WriteString(" ORDER BY ")
 
@@ -1031,7 +1820,7 @@ func (g *genericQueryBuilder) writeOrderBy(sb *strings.Builder, orderBy []OrderB
 				))
 			}
 
-			var sql, _ = g.FormatColumn(&field.Column)
+			var sql, _ = inf.FormatField(&field.Column)
 			sb.WriteString(sql)
 
 			if field.Desc {
@@ -1057,6 +1846,79 @@ func (g *genericQueryBuilder) writeLimitOffset(sb *strings.Builder, limit int, o
 	return args
 }
 
+// writeForUpdate writes the row locking clause of a select query.
+//
+// PostgreSQL and MySQL support `FOR UPDATE` and `FOR SHARE` with the `OF`, `NOWAIT` and `SKIP LOCKED` options.
+// MariaDB uses `LOCK IN SHARE MODE` for shared locks and does not support `OF`.
+// SQLite does not support row locking, nothing is written.
+func (g *genericQueryBuilder) writeForUpdate(sb *strings.Builder, inf *expr.ExpressionInfo, options ForUpdateOptions) error {
+	var mariaDB bool
+	switch g.driver.(type) {
+	case *drivers.DriverSQLite:
+		return nil
+	case *drivers.DriverMariaDB:
+		mariaDB = true
+	}
+
+	if options.NoWait && options.SkipLocked {
+		return fmt.Errorf("NOWAIT and SKIP LOCKED cannot be combined")
+	}
+
+	switch {
+	case options.Share && mariaDB:
+		sb.WriteString(" LOCK IN SHARE MODE")
+	case options.Share:
+		sb.WriteString(" FOR SHARE")
+	default:
+		sb.WriteString(" FOR UPDATE")
+	}
+
+	if len(options.Of) > 0 {
+		if mariaDB {
+			return fmt.Errorf(
+				"MariaDB does not support locking specific tables with FOR UPDATE OF: %w",
+				query_errors.ErrNotImplemented,
+			)
+		}
+
+		sb.WriteString(" OF ")
+		for i, path := range options.Of {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+
+			if path == "self" {
+				sb.WriteString(g.QuoteIdentifier(inf.Model.FieldDefs().TableName()))
+				continue
+			}
+
+			var _, _, field, _, _, _, err = internal.WalkFields(inf.Model, path, inf.AliasGen)
+			if err != nil {
+				return fmt.Errorf("FOR UPDATE OF %q: %w", path, err)
+			}
+
+			var rel = field.Rel()
+			if rel == nil {
+				return fmt.Errorf("FOR UPDATE OF %q: field is not a relation", path)
+			}
+
+			// the alias of the joined table, see [internal.WalkFields]
+			sb.WriteString(g.QuoteIdentifier(inf.AliasGen.GetTableAlias(
+				rel.Model().FieldDefs().TableName(), path,
+			)))
+		}
+	}
+
+	switch {
+	case options.NoWait:
+		sb.WriteString(" NOWAIT")
+	case options.SkipLocked:
+		sb.WriteString(" SKIP LOCKED")
+	}
+
+	return nil
+}
+
 type postgresQueryBuilder struct {
 	*genericQueryBuilder
 }
@@ -1070,6 +1932,35 @@ func NewPostgresQueryBuilder(db string) QueryCompiler {
 	return pgxCompiler
 }
 
+// PostgreSQL supports both ANALYZE and the output format as options, I.E. `EXPLAIN (ANALYZE, FORMAT JSON)`.
+func (g *postgresQueryBuilder) BuildExplainQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+	options ExplainOptions,
+) CompiledQuery[*QueryPlan] {
+	var opts = make([]string, 0, 2)
+	if options.Analyze {
+		opts = append(opts, "ANALYZE")
+	}
+
+	if options.Format != ExplainFormatDefault {
+		opts = append(opts, fmt.Sprintf("FORMAT %s", options.Format))
+	}
+
+	var explain = "EXPLAIN"
+	if len(opts) > 0 {
+		explain = fmt.Sprintf("EXPLAIN (%s)", strings.Join(opts, ", "))
+	}
+
+	var parse func(string) (*PlanNode, error)
+	if options.Format == ExplainFormatJSON {
+		parse = parsePostgresPlan
+	}
+
+	return g.explainQuery(ctx, qs, internals, explain, parse)
+}
+
 // getPostgresType returns the Postgres type for a given Go type and field.
 func getPostgresType(rTyp reflect.Type, field attrs.FieldDefinition) string {
 	switch rTyp.Kind() {
@@ -1187,6 +2078,27 @@ func NewMariaDBQueryBuilder(db string) QueryCompiler {
 	}
 }
 
+// MariaDB uses the ANALYZE statement instead of `EXPLAIN ANALYZE`.
+func (g *mariaDBQueryBuilder) BuildExplainQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+	options ExplainOptions,
+) CompiledQuery[*QueryPlan] {
+	var explain = "EXPLAIN"
+	if options.Analyze {
+		explain = "ANALYZE"
+	}
+
+	var parse func(string) (*PlanNode, error)
+	if options.Format == ExplainFormatJSON {
+		explain += " FORMAT=JSON"
+		parse = parseJSONPlan
+	}
+
+	return g.explainQuery(ctx, qs, internals, explain, parse)
+}
+
 func (g *mariaDBQueryBuilder) BuildUpdateQuery(
 	ctx context.Context,
 	qs *GenericQuerySet,
@@ -1264,6 +2176,190 @@ func NewMySQLQueryBuilder(db string) QueryCompiler {
 	}
 }
 
+// mysqlVersions caches the server version per database,
+// the version is only queried once per database connection name.
+var mysqlVersions sync.Map // map[string][3]int
+
+// MySQL only supports `LATERAL` joins starting from version 8.0.14.
+//
+// Older versions would return a syntax error, instead we check the server version
+// before executing the query and return a more descriptive error.
+func (g *mysqlQueryBuilder) BuildSelectQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+) CompiledQuery[[][]interface{}] {
+	var query = g.mariaDBQueryBuilder.BuildSelectQuery(ctx, qs, internals)
+	if !slices.ContainsFunc(internals.Joins, isLateralJoin) {
+		return query
+	}
+
+	return &QueryObject[[][]interface{}]{
+		QueryInformation: QueryInformation{
+			Builder: g,
+			Stmt:    query.SQL(),
+			Params:  query.Args(),
+			Object:  query.Model(),
+		},
+		Execute: func(sql string, args ...any) ([][]interface{}, error) {
+			if err := g.checkLateralSupport(ctx, internals); err != nil {
+				return nil, err
+			}
+			return query.(*QueryObject[[][]interface{}]).Execute(sql, args...)
+		},
+	}
+}
+
+// MySQL only supports INTERSECT and EXCEPT starting from version 8.0.31.
+//
+// Older versions would return a syntax error, instead we check the server version
+// before executing the query and return a more descriptive error.
+func (g *mysqlQueryBuilder) BuildCompoundQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+) CompiledQuery[[][]interface{}] {
+	var query = g.mariaDBQueryBuilder.BuildCompoundQuery(ctx, qs, internals)
+	return &QueryObject[[][]interface{}]{
+		QueryInformation: QueryInformation{
+			Builder: g,
+			Stmt:    query.SQL(),
+			Params:  query.Args(),
+			Object:  query.Model(),
+		},
+		Execute: func(sql string, args ...any) ([][]interface{}, error) {
+			if err := g.checkCompoundSupport(ctx, internals); err != nil {
+				return nil, err
+			}
+			return query.(*QueryObject[[][]interface{}]).Execute(sql, args...)
+		},
+	}
+}
+
+func (g *mysqlQueryBuilder) BuildCountQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+) CompiledQuery[int64] {
+	var query = g.mariaDBQueryBuilder.BuildCountQuery(ctx, qs, internals)
+	if len(internals.Combined) == 0 && !slices.ContainsFunc(internals.Joins, isLateralJoin) {
+		return query
+	}
+
+	return &QueryObject[int64]{
+		QueryInformation: QueryInformation{
+			Builder: g,
+			Stmt:    query.SQL(),
+			Params:  query.Args(),
+			Object:  query.Model(),
+		},
+		Execute: func(sql string, args ...any) (int64, error) {
+			if err := g.checkCompoundSupport(ctx, internals); err != nil {
+				return 0, err
+			}
+			if err := g.checkLateralSupport(ctx, internals); err != nil {
+				return 0, err
+			}
+			return query.(*QueryObject[int64]).Execute(sql, args...)
+		},
+	}
+}
+
+// MySQL returns the plan of `EXPLAIN ANALYZE` in the TREE format, the JSON
+// format is only supported for `EXPLAIN ANALYZE` by recent server versions.
+func (g *mysqlQueryBuilder) BuildExplainQuery(
+	ctx context.Context,
+	qs *GenericQuerySet,
+	internals *QuerySetInternals,
+	options ExplainOptions,
+) CompiledQuery[*QueryPlan] {
+	var explain = "EXPLAIN"
+	if options.Analyze {
+		explain = "EXPLAIN ANALYZE"
+	}
+
+	var parse func(string) (*PlanNode, error)
+	if options.Format == ExplainFormatJSON {
+		explain += " FORMAT=JSON"
+		parse = parseJSONPlan
+	}
+
+	return g.explainQuery(ctx, qs, internals, explain, parse)
+}
+
+func (g *mysqlQueryBuilder) checkCompoundSupport(ctx context.Context, internals *QuerySetInternals) error {
+	var typ CompoundType
+	for _, combined := range internals.Combined {
+		if combined.Type == CompoundIntersect || combined.Type == CompoundExcept {
+			typ = combined.Type
+			break
+		}
+	}
+
+	if typ == "" {
+		return nil
+	}
+
+	var v, err = g.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if v[0] < 8 || v[0] == 8 && v[1] == 0 && v[2] < 31 {
+		return fmt.Errorf(
+			"%s is not supported by MySQL %d.%d.%d, requires 8.0.31 or later: %w",
+			typ, v[0], v[1], v[2], query_errors.ErrNotImplemented,
+		)
+	}
+
+	return nil
+}
+
+// checkLateralSupport returns an error if the query has a `LATERAL` join,
+// these are only supported starting from MySQL 8.0.14.
+func (g *mysqlQueryBuilder) checkLateralSupport(ctx context.Context, internals *QuerySetInternals) error {
+	var idx = slices.IndexFunc(internals.Joins, isLateralJoin)
+
+	if idx == -1 {
+		return nil
+	}
+
+	var v, err = g.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if v[0] < 8 || v[0] == 8 && v[1] == 0 && v[2] < 14 {
+		return fmt.Errorf(
+			"LATERAL join %q is not supported by MySQL %d.%d.%d, requires 8.0.14 or later: %w",
+			internals.Joins[idx].Table.Alias, v[0], v[1], v[2], query_errors.ErrNotImplemented,
+		)
+	}
+
+	return nil
+}
+
+func isLateralJoin(join JoinDef) bool {
+	return join.Lateral
+}
+
+// serverVersion returns the version of the MySQL server, it is cached in [mysqlVersions].
+func (g *mysqlQueryBuilder) serverVersion(ctx context.Context) ([3]int, error) {
+	if version, ok := mysqlVersions.Load(g.DatabaseName()); ok {
+		return version.([3]int), nil
+	}
+
+	var versionStr string
+	var row = g.queryInfo.DB.QueryRowContext(ctx, "SELECT VERSION()")
+	if err := row.Scan(&versionStr); err != nil {
+		return [3]int{}, errors.Wrap(err, "failed to retrieve MySQL server version")
+	}
+
+	var version = parseVersion(versionStr)
+	mysqlVersions.Store(g.DatabaseName(), version)
+	return version, nil
+}
+
 // mysql does not properly support returning last insert id
 // when multiple rows are inserted, so we need to use a different approach.
 // This is a workaround to ensure that we can still return the last inserted ID
@@ -1342,6 +2438,8 @@ func (g *mysqlQueryBuilder) BuildCreateQuery(
 		query.WriteString(")")
 		values = append(values, object.Values...)
 
+		g.writeOnConflict(query, internals)
+
 		stmt = append(stmt, query.String())
 	}
 
@@ -1441,6 +2539,107 @@ func getDriverResult(res driver.Result, err error) (driver.Result, error) {
 // Helpers
 // -----------------------------------------------------------------------------
 
+// collectCTEs flattens the CTEs and the CTEs of their querysets into a single list.
+func collectCTEs(ctes []CTE, list []CTE, seen map[string]struct{}) []CTE {
+	for _, cte := range ctes {
+		list = collectCTEs(cte.QuerySet.internals.CTEs, list, seen)
+		if _, ok := seen[cte.Name]; ok {
+			continue
+		}
+		seen[cte.Name] = struct{}{}
+		list = append(list, cte)
+	}
+	return list
+}
+
+// errorQuery returns a query which returns the given error when executed.
+//
+// It is used when an error occurs while building the query.
+func errorQuery[T any](builder QueryCompiler, model attrs.Definer, err error) *QueryObject[T] {
+	return &QueryObject[T]{
+		QueryInformation: QueryInformation{
+			Builder: builder,
+			Stmt:    "",
+			Object:  model,
+			Params:  nil,
+		},
+		Execute: func(query string, args ...any) (T, error) {
+			return *new(T), err
+		},
+	}
+}
+
+// parseVersion parses a version string like "8.0.31-log" into its major, minor and patch numbers.
+func parseVersion(s string) [3]int {
+	var version [3]int
+	var parts = strings.SplitN(s, ".", 3)
+	for i, part := range parts {
+		var end = strings.IndexFunc(part, func(r rune) bool {
+			return r < '0' || r > '9'
+		})
+		if end >= 0 {
+			part = part[:end]
+		}
+		version[i], _ = strconv.Atoi(part)
+	}
+	return version
+}
+
+// countColumns returns the number of columns which are selected for the given fields.
+func countColumns[T attrs.FieldDefinition](fields []*FieldInfo[T]) int {
+	var amountCols = 0
+	for _, info := range fields {
+		if info.Through != nil {
+			amountCols += len(info.Through.Fields)
+		}
+		amountCols += len(info.Fields)
+	}
+	return amountCols
+}
+
+// compoundColumnPosition returns the (1-based) position of the column in the select list.
+//
+// The logic matches the order in which columns are written in [FieldInfo.WriteFields].
+func compoundColumnPosition[T attrs.FieldDefinition](inf *expr.ExpressionInfo, fields []*FieldInfo[T], col expr.TableColumn) (int, bool) {
+	var pos = 0
+	var matches = func(info *FieldInfo[T], field T) bool {
+		var tableAlias = info.Table.Alias
+		if tableAlias == "" {
+			tableAlias = info.Table.Name
+		}
+
+		if col.FieldAlias != "" {
+			var aliasField, ok = any(field).(AliasField)
+			return ok && aliasField.Alias() != "" &&
+				inf.AliasGen.GetFieldAlias(tableAlias, aliasField.Alias()) == col.FieldAlias
+		}
+
+		return col.FieldColumn != nil &&
+			col.TableOrAlias == tableAlias &&
+			field.ColumnName() == col.FieldColumn.ColumnName()
+	}
+
+	for _, info := range fields {
+		if info.Through != nil {
+			for _, field := range info.Through.Fields {
+				pos++
+				if matches(info.Through, field) {
+					return pos, true
+				}
+			}
+		}
+
+		for _, field := range info.Fields {
+			pos++
+			if matches(info, field) {
+				return pos, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
 func buildWhereClause(b *strings.Builder, inf *expr.ExpressionInfo, exprs []expr.ClauseExpression) []any {
 	var args = make([]any, 0)
 	for i, e := range exprs {
@@ -0,0 +1,94 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+)
+
+// CompoundType is the set operator used to combine two select queries.
+type CompoundType string
+
+const (
+	CompoundUnion     CompoundType = "UNION"
+	CompoundUnionAll  CompoundType = "UNION ALL"
+	CompoundIntersect CompoundType = "INTERSECT"
+	CompoundExcept    CompoundType = "EXCEPT"
+)
+
+// CombinedQuery is a queryset which is combined with
+// the select query of another queryset using a set operator.
+//
+// See [QuerySet.Union], [QuerySet.UnionAll], [QuerySet.Intersection] and [QuerySet.Difference].
+type CombinedQuery struct {
+	Type     CompoundType
+	QuerySet *GenericQuerySet
+}
+
+// Union combines the results of the queryset with the results of the other querysets.
+//
+// Duplicate rows are removed from the combined result, use [QuerySet.UnionAll] to keep them.
+//
+// All querysets must select the same number of columns, if no fields were
+// selected on the other querysets, all fields of their model will be selected.
+//
+// Ordering, limit and offset of the queryset (and any calls made after the union)
+// apply to the combined result, those of the other querysets are ignored.
+func (qs *QuerySet[T]) Union(others ...*QuerySet[T]) *QuerySet[T] {
+	return qs.combine(CompoundUnion, others)
+}
+
+// UnionAll combines the results of the queryset with the results of the other querysets.
+//
+// Unlike [QuerySet.Union], duplicate rows are kept in the combined result.
+//
+// Note that [QuerySet.All] will still return a single row per unique object.
+func (qs *QuerySet[T]) UnionAll(others ...*QuerySet[T]) *QuerySet[T] {
+	return qs.combine(CompoundUnionAll, others)
+}
+
+// Intersection returns only the rows which are present
+// both in the queryset and in all of the other querysets.
+//
+// MySQL only supports this starting from version 8.0.31.
+func (qs *QuerySet[T]) Intersection(others ...*QuerySet[T]) *QuerySet[T] {
+	return qs.combine(CompoundIntersect, others)
+}
+
+// Difference returns only the rows of the queryset
+// which are not present in any of the other querysets.
+//
+// MySQL only supports this starting from version 8.0.31.
+func (qs *QuerySet[T]) Difference(others ...*QuerySet[T]) *QuerySet[T] {
+	return qs.combine(CompoundExcept, others)
+}
+
+func (qs *QuerySet[T]) combine(typ CompoundType, others []*QuerySet[T]) *QuerySet[T] {
+	var nqs = qs.Clone()
+
+	if len(nqs.internals.Fields) == 0 {
+		nqs = nqs.Select("*")
+	}
+
+	for _, other := range others {
+		if other == nil {
+			panic(fmt.Errorf(
+				"QuerySet: cannot %s with a nil queryset: %w",
+				typ, query_errors.ErrNilPointer,
+			))
+		}
+
+		var oqs = other.Clone()
+		if len(oqs.internals.Fields) == 0 {
+			oqs = oqs.Select("*")
+		}
+
+		nqs.internals.Combined = append(nqs.internals.Combined, CombinedQuery{
+			Type:     typ,
+			QuerySet: ChangeObjectsType[T, attrs.Definer](oqs),
+		})
+	}
+
+	return nqs
+}
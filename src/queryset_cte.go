@@ -0,0 +1,205 @@
+package queries
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/src/expr"
+)
+
+// CTE is a common table expression which is added to the query with [QuerySet.With].
+//
+// The columns of the CTE are the columns selected by its queryset.
+type CTE struct {
+	Name      string
+	QuerySet  *GenericQuerySet
+	Recursive bool
+}
+
+// With adds a common table expression to the query, I.E. `WITH name AS (...)`.
+//
+// The CTE can be referenced as if it were a table by name, see [QuerySet.JoinCTE], [CTEColumn] and [CTESubquery].
+//
+// A recursive CTE should be built out of a non-recursive queryset which is combined with
+// [QuerySet.Union] or [QuerySet.UnionAll] with a queryset that references the CTE itself, I.E.:
+//
+//	var base = queries.GetQuerySet[attrs.Definer](&Category{}).
+//		Select("ID", "Name", "Parent").
+//		Filter("ID", root.ID)
+//
+//	var children = queries.GetQuerySet[attrs.Definer](&Category{}).
+//		Select("ID", "Name", "Parent").
+//		JoinCTE("tree", queries.TypeJoinInner, "Parent", "id")
+//
+//	var rows, err = queries.GetQuerySet(&Category{}).
+//		With("tree", base.UnionAll(children), true).
+//		Filter("ID__in", queries.CTESubquery("tree", "id")).
+//		All()
+//
+// CTEs which were added to the CTE queryset itself are moved to the outer query.
+func (qs *QuerySet[T]) With(name string, cte *GenericQuerySet, recursive bool) *QuerySet[T] {
+	if name == "" {
+		panic("QuerySet.With: CTE name cannot be empty")
+	}
+
+	if cte == nil {
+		panic(fmt.Errorf("QuerySet.With: CTE %q cannot be nil", name))
+	}
+
+	var cteQs = cte.Clone()
+	if len(cteQs.internals.Fields) == 0 {
+		cteQs = cteQs.Select("*")
+	}
+
+	// a CTE should not be limited by default,
+	// only when a limit was explicitly set.
+	if cteQs.internals.Limit == MAX_DEFAULT_RESULTS {
+		cteQs.internals.Limit = 0
+	}
+
+	var nqs = qs.Clone()
+	nqs.internals.CTEs = append(nqs.internals.CTEs, CTE{
+		Name:      name,
+		QuerySet:  cteQs,
+		Recursive: recursive,
+	})
+	return nqs
+}
+
+// JoinCTE joins a common table expression to the query.
+//
+// The field is the name of a field on the model of the queryset,
+// the column is the name of the column of the CTE to join on, I.E.:
+//
+//	qs.JoinCTE("tree", queries.TypeJoinInner, "Parent", "id")
+//
+// will result in `INNER JOIN tree ON categories.parent_id = tree.id`.
+//
+// The CTE itself needs to be added to the outermost query with [QuerySet.With].
+func (qs *QuerySet[T]) JoinCTE(name string, typ JoinType, field string, column string) *QuerySet[T] {
+	var nqs = qs.Clone()
+	var tableAlias, f, err = nqs.resolveJoinField(field)
+	if err != nil {
+		panic(fmt.Errorf("QuerySet.JoinCTE: %w", err))
+	}
+
+	nqs.internals.AddJoin(JoinDef{
+		Table: Table{
+			Name: name,
+		},
+		TypeJoin: typ,
+		JoinDefCondition: &JoinDefCondition{
+			Operator: expr.EQ,
+			ConditionA: expr.TableColumn{
+				TableOrAlias: tableAlias,
+				FieldColumn:  f,
+			},
+			ConditionB: expr.TableColumn{
+				TableOrAlias: name,
+				FieldAlias:   column,
+			},
+		},
+	})
+	return nqs
+}
+
+var _ expr.SubqueryExpression = (*cteExpr)(nil)
+
+type cteExpr struct {
+	name    string
+	columns []string
+	sql     string
+	query   bool
+	used    bool
+}
+
+// CTEColumn returns an expression which references the column of a common table expression, I.E. `tree.depth`.
+//
+// The CTE has to be part of the query, for example by joining it with [QuerySet.JoinCTE].
+func CTEColumn(name, column string) expr.Expression {
+	return &cteExpr{
+		name:    name,
+		columns: []string{column},
+	}
+}
+
+// CTESubquery returns an expression which selects the given columns
+// from a common table expression, I.E. `(SELECT id FROM tree)`.
+//
+// It can be used like any other subquery, I.E. `Filter("ID__in", CTESubquery("tree", "id"))`.
+func CTESubquery(name string, columns ...string) expr.Expression {
+	if len(columns) == 0 {
+		panic(fmt.Errorf("CTESubquery: no columns provided for CTE %q", name))
+	}
+
+	return &cteExpr{
+		name:    name,
+		columns: columns,
+		query:   true,
+	}
+}
+
+func (c *cteExpr) SQL(sb *strings.Builder) []any {
+	if !c.query {
+		return c.SubquerySQL(sb)
+	}
+
+	sb.WriteString("(")
+	var args = c.SubquerySQL(sb)
+	sb.WriteString(")")
+	return args
+}
+
+// SubquerySQL writes the column, or the subquery without parentheses.
+func (c *cteExpr) SubquerySQL(sb *strings.Builder) []any {
+	if !c.used {
+		panic(fmt.Errorf("CTE expression for %q was not resolved, cannot generate SQL", c.name))
+	}
+
+	sb.WriteString(c.sql)
+	return []any{}
+}
+
+func (c *cteExpr) Clone() expr.Expression {
+	return &cteExpr{
+		name:    c.name,
+		columns: c.columns,
+		sql:     c.sql,
+		query:   c.query,
+		used:    c.used,
+	}
+}
+
+func (c *cteExpr) Resolve(inf *expr.ExpressionInfo) expr.Expression {
+	if inf.Model == nil || c.used {
+		return c
+	}
+
+	var nE = c.Clone().(*cteExpr)
+	nE.used = true
+
+	var sb = new(strings.Builder)
+	if nE.query {
+		sb.WriteString("SELECT ")
+	}
+
+	for i, column := range nE.columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		var sql, _ = inf.FormatField(&expr.TableColumn{
+			TableOrAlias: nE.name,
+			FieldAlias:   column,
+		})
+		sb.WriteString(sql)
+	}
+
+	if nE.query {
+		sb.WriteString(" FROM ")
+		sb.WriteString(inf.QuoteIdentifier(nE.name))
+	}
+
+	nE.sql = sb.String()
+	return nE
+}
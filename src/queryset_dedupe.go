@@ -2,6 +2,7 @@ package queries
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/Nigel2392/go-django-queries/src/query_errors"
 	"github.com/Nigel2392/go-django/src/core/attrs"
@@ -336,6 +337,11 @@ func (r *rows[T]) compile(qs *QuerySet[T]) (Rows[T], error) {
 			throughSetter.SetThroughModel(obj.object.through)
 		}
 
+		// Inform the definer of the fields which were not loaded from the database.
+		if setter, ok := definer.(DeferredFieldsSetter); ok && len(qs.internals.Deferred) > 0 {
+			setter.SetDeferredFields(slices.Clone(qs.internals.Deferred))
+		}
+
 		root = append(root, &Row[T]{
 			QuerySet:    qs,
 			Through:     obj.object.through,
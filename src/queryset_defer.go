@@ -0,0 +1,126 @@
+package queries
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Nigel2392/go-django/src/core/attrs"
+)
+
+// Defer is used to exclude fields of the model from the selection.
+//
+// The deferred fields are not loaded from the database and keep their zero value,
+// this is useful for large text or binary fields which are rarely needed when listing objects.
+//
+// Objects which implement [DeferredFieldsSetter] are informed of the deferred fields,
+// the Model of the models package excludes them when saving and can load them on demand.
+//
+// Only fields of the queryset's model can be deferred, the primary key can not be deferred.
+// If no fields are selected yet, all fields are selected, the deferred fields are also excluded
+// from any fields selected later on with [QuerySet.Select].
+func (qs *QuerySet[T]) Defer(fields ...string) *QuerySet[T] {
+	var nqs = qs.Clone()
+	var defs = nqs.internals.Model.Object.FieldDefs()
+	for _, name := range fields {
+		var field, ok = defs.Field(name)
+		if !ok || field.ColumnName() == "" {
+			panic(fmt.Errorf("Defer: field %q not found in %T", name, nqs.internals.Model.Object))
+		}
+
+		if field.IsPrimary() {
+			panic(fmt.Errorf("Defer: cannot defer primary key %q of %T", name, nqs.internals.Model.Object))
+		}
+
+		if !slices.Contains(nqs.internals.Deferred, name) {
+			nqs.internals.Deferred = append(nqs.internals.Deferred, name)
+		}
+	}
+
+	if len(nqs.internals.Fields) == 0 {
+		return nqs.Select("*")
+	}
+
+	nqs.deferFields()
+	return nqs
+}
+
+// Only is used to only load the given fields of the model, all other fields are deferred.
+//
+// The primary key is always loaded, relations can be selected by passing their path, I.E.:
+//
+//	Only("Title", "Author.*")
+//
+// will load the title and primary key of the model, and all fields of the author.
+//
+// See [QuerySet.Defer] for more information about deferred fields.
+func (qs *QuerySet[T]) Only(fields ...string) *QuerySet[T] {
+	var (
+		nqs       = qs.Clone()
+		defs      = nqs.internals.Model.Object.FieldDefs()
+		only      = make(map[string]struct{}, len(fields))
+		relations = make([]any, 0, len(fields)+1)
+	)
+
+	relations = append(relations, "*")
+	for _, name := range fields {
+		if strings.Contains(name, ".") {
+			relations = append(relations, name)
+			continue
+		}
+
+		if _, ok := defs.Field(name); !ok {
+			panic(fmt.Errorf("Only: field %q not found in %T", name, nqs.internals.Model.Object))
+		}
+
+		only[name] = struct{}{}
+	}
+
+	nqs.internals.Deferred = make([]string, 0)
+	for _, field := range ForSelectAllFields[attrs.FieldDefinition](defs) {
+		if _, ok := only[field.Name()]; ok || field.IsPrimary() || field.ColumnName() == "" {
+			continue
+		}
+		nqs.internals.Deferred = append(nqs.internals.Deferred, field.Name())
+	}
+
+	return nqs.Select(relations...)
+}
+
+// deferFields removes the deferred fields from the selected fields of the queryset's model.
+//
+// Fields of related models, annotations and expressions are left untouched.
+func (qs *QuerySet[T]) deferFields() {
+	if len(qs.internals.Deferred) == 0 {
+		return
+	}
+
+	var (
+		modelType = reflect.TypeOf(qs.internals.Model.Object)
+		infos     = make([]*FieldInfo[attrs.FieldDefinition], 0, len(qs.internals.Fields))
+	)
+
+	for _, info := range qs.internals.Fields {
+		if info.Model == nil || len(info.Chain) > 0 || info.Through != nil || reflect.TypeOf(info.Model) != modelType {
+			infos = append(infos, info)
+			continue
+		}
+
+		// the field info might be shared with the queryset this one was cloned from
+		var newInfo = *info
+		newInfo.Fields = make([]attrs.FieldDefinition, 0, len(info.Fields))
+		for _, field := range info.Fields {
+			if _, ok := field.(*exprField); !ok && slices.Contains(qs.internals.Deferred, field.Name()) {
+				continue
+			}
+			newInfo.Fields = append(newInfo.Fields, field)
+		}
+
+		if len(newInfo.Fields) > 0 {
+			infos = append(infos, &newInfo)
+		}
+	}
+
+	qs.internals.Fields = infos
+}
@@ -0,0 +1,341 @@
+package queries
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/pkg/errors"
+)
+
+// ExplainFormat is the output format of an EXPLAIN query.
+type ExplainFormat string
+
+const (
+	// ExplainFormatDefault uses the default output format of the database.
+	ExplainFormatDefault ExplainFormat = ""
+	ExplainFormatText    ExplainFormat = "TEXT"
+	ExplainFormatJSON    ExplainFormat = "JSON"
+)
+
+// ExplainOptions are passed to [QueryCompiler.BuildExplainQuery] to configure the EXPLAIN query.
+type ExplainOptions struct {
+	// Analyze executes the query and includes the actual run time statistics in the plan.
+	//
+	// This is not supported by SQLite.
+	Analyze bool
+
+	// Format is the output format of the plan, SQLite only has a single output format and ignores it.
+	Format ExplainFormat
+}
+
+// ExplainOption is used to configure [QuerySet.Explain] and [QuerySet.ExplainPlan].
+type ExplainOption func(*ExplainOptions)
+
+// ExplainAnalyze executes the query and includes the actual run time statistics in the plan.
+//
+// Note that the query is actually executed, the results are discarded.
+func ExplainAnalyze() ExplainOption {
+	return func(o *ExplainOptions) {
+		o.Analyze = true
+	}
+}
+
+// ExplainAs sets the output format of the plan.
+func ExplainAs(format ExplainFormat) ExplainOption {
+	return func(o *ExplainOptions) {
+		o.Format = format
+	}
+}
+
+// QueryPlan is the result of an EXPLAIN query.
+type QueryPlan struct {
+	// Output is the plan as returned by the database,
+	// rows are separated by newlines.
+	Output string
+
+	// Root is the root of the parsed plan,
+	// it is nil if the output could not be parsed.
+	Root *PlanNode
+}
+
+// PlanNode is a single step in a query plan.
+//
+// The root node of a plan has the detail "QUERY PLAN", the steps of the plan are its children.
+type PlanNode struct {
+	// Detail describes the step, I.E. `SCAN todos`
+	// for SQLite or the node type for PostgreSQL.
+	Detail string
+
+	// Properties holds any other information about the step,
+	// such as the estimated cost or the number of rows.
+	Properties map[string]any
+
+	Children []*PlanNode
+}
+
+// Walk calls fn for the node and all of its descendants, depth first.
+//
+// The walk stops if fn returns false.
+func (n *PlanNode) Walk(fn func(node *PlanNode, depth int) bool) {
+	n.walk(fn, 0)
+}
+
+func (n *PlanNode) walk(fn func(node *PlanNode, depth int) bool, depth int) bool {
+	if !fn(n, depth) {
+		return false
+	}
+	for _, child := range n.Children {
+		if !child.walk(fn, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the plan as a tree, I.E.:
+//
+//	QUERY PLAN
+//	|--SCAN todos
+//	`--SEARCH users USING INTEGER PRIMARY KEY (rowid=?)
+func (n *PlanNode) String() string {
+	var sb = new(strings.Builder)
+	sb.WriteString(n.Detail)
+	n.writeChildren(sb, "")
+	return sb.String()
+}
+
+func (n *PlanNode) writeChildren(sb *strings.Builder, prefix string) {
+	for i, child := range n.Children {
+		var last = i == len(n.Children)-1
+		sb.WriteString("\n")
+		sb.WriteString(prefix)
+		if last {
+			sb.WriteString("`--")
+		} else {
+			sb.WriteString("|--")
+		}
+		sb.WriteString(child.Detail)
+
+		if last {
+			child.writeChildren(sb, prefix+"   ")
+		} else {
+			child.writeChildren(sb, prefix+"|  ")
+		}
+	}
+}
+
+// Explain returns the query plan of the select query of the queryset as returned by the database.
+//
+// The query is wrapped in `EXPLAIN QUERY PLAN` for SQLite, `EXPLAIN` for PostgreSQL, MySQL and MariaDB.
+// The options can be used to analyze the query or to change the output format, I.E.:
+//
+//	qs.Explain(queries.ExplainAnalyze(), queries.ExplainAs(queries.ExplainFormatJSON))
+//
+// will use `EXPLAIN (ANALYZE, FORMAT JSON)` for PostgreSQL.
+func (qs *QuerySet[T]) Explain(opts ...ExplainOption) (string, error) {
+	var plan, err = qs.explain(opts)
+	if err != nil {
+		return "", err
+	}
+	return plan.Output, nil
+}
+
+// ExplainPlan returns the query plan of the select query of the queryset parsed into a tree of [PlanNode].
+//
+// The JSON output format is used for databases which support it, see [QuerySet.Explain] for the options.
+func (qs *QuerySet[T]) ExplainPlan(opts ...ExplainOption) (*PlanNode, error) {
+	var plan, err = qs.explain(append(opts, ExplainAs(ExplainFormatJSON)))
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Root == nil {
+		return nil, fmt.Errorf(
+			"QuerySet.ExplainPlan: could not parse query plan: %w",
+			query_errors.ErrNotImplemented,
+		)
+	}
+
+	return plan.Root, nil
+}
+
+func (qs *QuerySet[T]) explain(opts []ExplainOption) (*QueryPlan, error) {
+	var options ExplainOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var nqs = qs.Clone()
+	if len(nqs.internals.Fields) == 0 {
+		nqs = nqs.Select("*")
+	}
+
+	var query = nqs.compiler.BuildExplainQuery(
+		nqs.context,
+		ChangeObjectsType[T, attrs.Definer](nqs),
+		nqs.internals,
+		options,
+	)
+	qs.latestQuery = query
+
+	var plan, err = query.Exec()
+	if err != nil {
+		return nil, errors.Wrap(err, "QuerySet.Explain")
+	}
+
+	return plan, nil
+}
+
+// scanExplainRows reads the rows returned by an EXPLAIN query into a string.
+//
+// Single column output is joined by newlines, any other output
+// is rendered as a tab separated table with a header row.
+func scanExplainRows(rows drivers.SQLRows) (string, error) {
+	var columns, err = rows.Columns()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve columns")
+	}
+
+	var lines = make([]string, 0, 1)
+	if len(columns) > 1 {
+		lines = append(lines, strings.Join(columns, "\t"))
+	}
+
+	for rows.Next() {
+		var values = make([]any, len(columns))
+		var row = make([]any, len(columns))
+		for i := range row {
+			row[i] = &values[i]
+		}
+
+		if err := rows.Scan(row...); err != nil {
+			return "", errors.Wrap(err, "failed to scan row")
+		}
+
+		var cols = make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				cols[i] = "NULL"
+			case []byte:
+				cols[i] = string(v)
+			default:
+				cols[i] = fmt.Sprint(v)
+			}
+		}
+
+		lines = append(lines, strings.Join(cols, "\t"))
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", errors.Wrap(err, "failed to iterate rows")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// parsePostgresPlan parses the JSON output of a PostgreSQL EXPLAIN query.
+//
+// Each node is named after its node type, the sub- plans are its children.
+func parsePostgresPlan(output string) (*PlanNode, error) {
+	var plans []map[string]any
+	if err := json.Unmarshal([]byte(output), &plans); err != nil {
+		return nil, err
+	}
+
+	var root = &PlanNode{
+		Detail:     "QUERY PLAN",
+		Properties: make(map[string]any),
+	}
+
+	var parse func(plan map[string]any) *PlanNode
+	parse = func(plan map[string]any) *PlanNode {
+		var node = &PlanNode{
+			Properties: make(map[string]any, len(plan)),
+		}
+
+		for key, value := range plan {
+			switch key {
+			case "Node Type":
+				node.Detail = fmt.Sprint(value)
+			case "Plans":
+				var children, _ = value.([]any)
+				for _, child := range children {
+					if child, ok := child.(map[string]any); ok {
+						node.Children = append(node.Children, parse(child))
+					}
+				}
+			default:
+				node.Properties[key] = value
+			}
+		}
+
+		return node
+	}
+
+	for _, plan := range plans {
+		for key, value := range plan {
+			if p, ok := value.(map[string]any); ok && key == "Plan" {
+				root.Children = append(root.Children, parse(p))
+				continue
+			}
+
+			// planning and execution time, triggers etc.
+			root.Properties[key] = value
+		}
+	}
+
+	return root, nil
+}
+
+// parseJSONPlan parses the JSON output of a MySQL or MariaDB EXPLAIN query.
+//
+// Each JSON object becomes a node named after its key, I.E. `query_block` or `table`,
+// any other values are stored as properties of the node.
+func parseJSONPlan(output string) (*PlanNode, error) {
+	var plan map[string]any
+	if err := json.Unmarshal([]byte(output), &plan); err != nil {
+		return nil, err
+	}
+
+	var parse func(detail string, obj map[string]any) *PlanNode
+	parse = func(detail string, obj map[string]any) *PlanNode {
+		var node = &PlanNode{
+			Detail:     detail,
+			Properties: make(map[string]any),
+		}
+
+		// keys are sorted to keep the order of the children stable
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			switch value := obj[key].(type) {
+			case map[string]any:
+				node.Children = append(node.Children, parse(key, value))
+			case []any:
+				var children = make([]*PlanNode, 0, len(value))
+				for _, item := range value {
+					if item, ok := item.(map[string]any); ok {
+						children = append(children, parse(key, item))
+					}
+				}
+
+				if len(children) == len(value) && len(children) > 0 {
+					node.Children = append(node.Children, children...)
+				} else {
+					node.Properties[key] = value
+				}
+			default:
+				node.Properties[key] = value
+			}
+		}
+
+		return node
+	}
+
+	return parse("QUERY PLAN", plan), nil
+}
@@ -0,0 +1,93 @@
+package queries
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/pkg/errors"
+)
+
+// MAX_IN_BULK_PARAMS is the maximum number of values passed to a single `IN` query by [QuerySet.InBulk].
+//
+// It stays below the default maximum number of parameters of older SQLite versions (999),
+// leaving room for the parameters of any other filters on the queryset.
+var MAX_IN_BULK_PARAMS = 900
+
+// InBulk is used to retrieve the objects which have one of the given values for a unique field,
+// the objects are returned in a map keyed by the value of that field.
+//
+// The field defaults to the primary key, any other field must be unique.
+// It can be used like so:
+//
+//	var posts, err = qs.InBulk([]any{"my-first-post", "my-second-post"}, "Slug")
+//
+// The map is keyed by the value of the field as it is stored on the object,
+// values which do not match any object are not present in the map.
+//
+// The values are split into batches of [MAX_IN_BULK_PARAMS] values to stay below the parameter limits of the database,
+// the filters of the queryset apply to each batch.
+func (qs *QuerySet[T]) InBulk(values []any, field ...string) (map[any]T, error) {
+	if len(field) > 1 {
+		return nil, fmt.Errorf(
+			"QuerySet.InBulk: expected at most one field for %T, got %d",
+			qs.internals.Model.Object, len(field),
+		)
+	}
+
+	var (
+		defs = qs.internals.Model.Object.FieldDefs()
+		fld  attrs.FieldDefinition
+	)
+
+	if len(field) == 0 {
+		fld = defs.Primary()
+		if fld == nil {
+			return nil, fmt.Errorf(
+				"QuerySet.InBulk: %T has no primary key: %w",
+				qs.internals.Model.Object, query_errors.ErrNoUniqueKey,
+			)
+		}
+	} else {
+		var ok bool
+		fld, ok = defs.Field(field[0])
+		if !ok || fld.ColumnName() == "" {
+			return nil, fmt.Errorf(
+				"QuerySet.InBulk: field %q not found in %T: %w",
+				field[0], qs.internals.Model.Object, query_errors.ErrFieldNotFound,
+			)
+		}
+
+		if !fld.IsPrimary() && !slices.ContainsFunc(
+			getMetaUniqueFields(attrs.GetModelMeta(qs.internals.Model.Object)),
+			func(fields []string) bool { return len(fields) == 1 && fields[0] == fld.Name() },
+		) {
+			return nil, fmt.Errorf(
+				"QuerySet.InBulk: field %q of %T is not unique: %w",
+				fld.Name(), qs.internals.Model.Object, query_errors.ErrNoUniqueKey,
+			)
+		}
+	}
+
+	var (
+		lookup  = fmt.Sprintf("%s__in", fld.Name())
+		objects = make(map[any]T, len(values))
+	)
+
+	for batch := range slices.Chunk(values, max(MAX_IN_BULK_PARAMS, 1)) {
+		var rows, err = qs.Filter(lookup, batch...).Limit(len(batch)).All()
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "QuerySet.InBulk: failed to retrieve objects of %T", qs.internals.Model.Object,
+			)
+		}
+
+		for _, row := range rows {
+			var f, _ = row.Object.FieldDefs().Field(fld.Name())
+			objects[f.GetValue()] = row.Object
+		}
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,227 @@
+package queries
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/Nigel2392/go-django-queries/internal"
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/Nigel2392/go-django/src/core/logger"
+	"github.com/pkg/errors"
+)
+
+// Iterator retrieves the rows of the queryset one by one without loading the full result set into memory.
+//
+// Rows are scanned from the database as they are iterated over, objects are built and
+// passed to [ActsAfterQuery] in chunks of chunkSize rows.
+//
+// The cursor stays open while the objects are yielded, the loop body and [ActsAfterQuery]
+// must not execute queries on the same connection, I.E. inside of a transaction or with a single pgx connection,
+// the database would return a "conn busy" error.
+//
+// If the queryset prefetches related objects, the chunks are retrieved with keyset pagination instead, see [QuerySet.Paginate].
+// Each chunk is read fully and its cursor is closed before the prefetches are executed and the objects are yielded,
+// the ordering must follow the rules of [QuerySet.Paginate] and an offset cannot be used.
+//
+// The default limit of the queryset is not applied, only a limit that was explicitly set.
+//
+// Related objects can be selected as long as they are not multi-valued, I.E. forward foreign keys
+// and one-to-one relations. Reverse foreign keys and many-to-many relations span multiple rows for
+// a single object, a [query_errors.ErrDedupeRequired] error is returned for those, use [QuerySet.Prefetch] instead.
+//
+// Iteration stops after the first error.
+//
+//	for obj, err := range queries.GetQuerySet(&Todo{}).Iterator(500) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (qs *QuerySet[T]) Iterator(chunkSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if chunkSize <= 0 {
+			yield(*new(T), fmt.Errorf("QuerySet.Iterator: chunk size must be greater than 0, got %d", chunkSize))
+			return
+		}
+
+		var nqs = qs.Clone()
+		if nqs.internals.Limit == MAX_DEFAULT_RESULTS {
+			nqs.internals.Limit = 0
+		}
+
+		var query = nqs.queryAll()
+		if query.SQL() == "" {
+			// the query failed to compile, executing
+			// it will return the compilation error
+			var _, err = query.Exec()
+			yield(*new(T), errors.Wrap(err, "QuerySet.Iterator"))
+			return
+		}
+
+		var newChunk = func() (*rows[T], error) {
+			return newRows[T](
+				nqs.internals.Fields,
+				internal.NewObjectFromIface(nqs.internals.Model.Object),
+				func(o attrs.Definer) error {
+					if o == nil {
+						return nil
+					}
+					return runActor(
+						actsAfterQuery, o,
+						ChangeObjectsType[T, attrs.Definer](nqs),
+					)
+				},
+			)
+		}
+
+		var chunk, err = newChunk()
+		if err != nil {
+			yield(*new(T), errors.Wrap(err, "QuerySet.Iterator: failed to create rows"))
+			return
+		}
+
+		if chunk.hasMultiRelations {
+			yield(*new(T), fmt.Errorf(
+				"QuerySet.Iterator: cannot iterate over multi-valued relations of %T: %w",
+				nqs.internals.Model.Object, query_errors.ErrDedupeRequired,
+			))
+			return
+		}
+
+		if len(nqs.internals.Prefetch) > 0 {
+			nqs.iterateKeyset(chunkSize, yield)
+			return
+		}
+
+		if LogQueries {
+			logger.Debugf("Query (%T, Iterator): %s %v", query.Model(), query.SQL(), query.Args())
+		}
+
+		dbRows, err := nqs.compiler.DB().QueryContext(nqs.context, query.SQL(), query.Args()...)
+		if err != nil {
+			yield(*new(T), errors.Wrap(err, "QuerySet.Iterator: failed to execute query"))
+			return
+		}
+		defer dbRows.Close()
+
+		// flush compiles the current chunk and yields its objects,
+		// it returns false if the iteration should stop.
+		var flush = func() bool {
+			var compiled, err = chunk.compile(nqs)
+			if err != nil {
+				yield(*new(T), errors.Wrap(err, "QuerySet.Iterator"))
+				return false
+			}
+
+			for _, row := range compiled {
+				if !yield(row.Object, nil) {
+					return false
+				}
+			}
+
+			chunk, err = newChunk()
+			if err != nil {
+				yield(*new(T), errors.Wrap(err, "QuerySet.Iterator: failed to create rows"))
+				return false
+			}
+			return true
+		}
+
+		var (
+			amountCols  = countColumns(nqs.internals.Fields)
+			resultIndex = 0
+			chunkLen    = 0
+		)
+
+		for dbRows.Next() {
+			var row = make([]interface{}, amountCols)
+			for i := range row {
+				row[i] = new(interface{})
+			}
+
+			if err := dbRows.Scan(row...); err != nil {
+				yield(*new(T), errors.Wrap(err, "QuerySet.Iterator: failed to scan row"))
+				return
+			}
+
+			for i, iface := range row {
+				row[i] = *(iface.(*interface{}))
+			}
+
+			if err := nqs.addRow(chunk, row, resultIndex); err != nil {
+				yield(*new(T), errors.Wrap(err, "QuerySet.Iterator"))
+				return
+			}
+
+			resultIndex++
+			chunkLen++
+
+			if chunkLen >= chunkSize {
+				if !flush() {
+					return
+				}
+				chunkLen = 0
+			}
+		}
+
+		if err := dbRows.Err(); err != nil {
+			yield(*new(T), errors.Wrap(err, "QuerySet.Iterator: failed to iterate rows"))
+			return
+		}
+
+		if chunkLen > 0 {
+			flush()
+		}
+	}
+}
+
+// iterateKeyset yields the objects of the queryset in chunks which are retrieved with [QuerySet.Paginate].
+//
+// No cursor is open while the prefetches are executed or the objects are yielded.
+func (qs *QuerySet[T]) iterateKeyset(chunkSize int, yield func(T, error) bool) {
+	if qs.internals.Offset > 0 {
+		yield(*new(T), fmt.Errorf(
+			"QuerySet.Iterator: cannot use an offset when prefetching related objects: %w",
+			query_errors.ErrNotImplemented,
+		))
+		return
+	}
+
+	var (
+		remaining = qs.internals.Limit
+		cursor    string
+	)
+
+	for {
+		var pageSize = chunkSize
+		if remaining > 0 && remaining < pageSize {
+			pageSize = remaining
+		}
+
+		var page, err = qs.Paginate(cursor, pageSize)
+		if err != nil {
+			yield(*new(T), errors.Wrap(err, "QuerySet.Iterator"))
+			return
+		}
+
+		for _, row := range page.Rows {
+			if !yield(row.Object, nil) {
+				return
+			}
+		}
+
+		if remaining > 0 {
+			remaining -= len(page.Rows)
+			if remaining <= 0 {
+				return
+			}
+		}
+
+		if !page.HasNext() {
+			return
+		}
+
+		cursor = page.Next
+	}
+}
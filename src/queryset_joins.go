@@ -0,0 +1,171 @@
+package queries
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/internal"
+	"github.com/Nigel2392/go-django-queries/src/expr"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+)
+
+// JoinSubquery joins the subquery to the query as a derived table, I.E. `INNER JOIN (SELECT ...) AS alias ON ...`.
+//
+// The field is the name of a field on the model of the queryset,
+// the column is the name of a field or annotation of the subquery to join on, I.E.:
+//
+//	var counts = queries.GetQuerySet[attrs.Definer](&Todo{}).
+//		Select("User").
+//		Annotate("Count", expr.COUNT("ID")).
+//		GroupBy("User")
+//
+//	var rows, err = queries.GetQuerySet(&User{}).
+//		JoinSubquery("todos", queries.TypeJoinInner, counts, "ID", "User").
+//		Select("*", "todos.Count").
+//		Filter("todos.Count__gt", 1).
+//		OrderBy("-todos.Count").
+//		All()
+//
+// The fields and annotations of the subquery can be referenced through the alias in
+// [QuerySet.Select], [QuerySet.Filter] and [QuerySet.OrderBy], I.E. `todos.Count`.
+// Selected columns of the subquery are stored in the annotations of the row.
+//
+// CTEs which were added to the subquery itself are moved to the outer query.
+func (qs *QuerySet[T]) JoinSubquery(alias string, typ JoinType, subquery *GenericQuerySet, field string, column string) *QuerySet[T] {
+	var nqs, sub = qs.joinSubquery("JoinSubquery", alias, subquery)
+	var tableAlias, f, err = nqs.resolveJoinField(field)
+	if err != nil {
+		panic(fmt.Errorf("QuerySet.JoinSubquery: %w", err))
+	}
+
+	nqs.internals.AddJoin(JoinDef{
+		Table: Table{
+			Alias: alias,
+		},
+		TypeJoin: typ,
+		JoinDefCondition: &JoinDefCondition{
+			Operator: expr.EQ,
+			ConditionA: expr.TableColumn{
+				TableOrAlias: tableAlias,
+				FieldColumn:  f,
+			},
+			ConditionB: expr.TableColumn{
+				TableOrAlias: alias,
+				FieldAlias:   sub.subqueryColumnName(column),
+			},
+		},
+		Subquery: sub,
+	})
+	return nqs
+}
+
+// JoinLateral joins the subquery to the query with `LATERAL`, I.E. `CROSS JOIN LATERAL (SELECT ...) AS alias`.
+//
+// A lateral subquery can reference the columns of the outer query with [expr.OuterRef],
+// it is joined without a join condition, I.E. to select the latest todo of each user:
+//
+//	var latest = queries.GetQuerySet[attrs.Definer](&Todo{}).
+//		Select("ID", "Title").
+//		Filter("User", expr.OuterRef("ID")).
+//		OrderBy("-ID").
+//		Limit(1)
+//
+//	var rows, err = queries.GetQuerySet(&User{}).
+//		JoinLateral("latest", queries.TypeJoinLeft, latest).
+//		Select("*", "latest.Title").
+//		All()
+//
+// The columns of the subquery can be referenced through the alias, see [QuerySet.JoinSubquery].
+//
+// Lateral joins are only supported on PostgreSQL and MySQL 8.0.14 or later,
+// other databases return [query_errors.ErrNotImplemented] when the query is executed.
+func (qs *QuerySet[T]) JoinLateral(alias string, typ JoinType, subquery *GenericQuerySet) *QuerySet[T] {
+	var nqs, sub = qs.joinSubquery("JoinLateral", alias, subquery)
+	nqs.internals.AddJoin(JoinDef{
+		Table: Table{
+			Alias: alias,
+		},
+		TypeJoin: typ,
+		Subquery: sub,
+		Lateral:  true,
+	})
+	return nqs
+}
+
+// joinSubquery validates and prepares a subquery to be joined as a derived table.
+func (qs *QuerySet[T]) joinSubquery(method, alias string, subquery *GenericQuerySet) (*QuerySet[T], *GenericQuerySet) {
+	if alias == "" {
+		panic(fmt.Errorf("QuerySet.%s: alias cannot be empty", method))
+	}
+
+	if strings.Contains(alias, ".") {
+		panic(fmt.Errorf("QuerySet.%s: alias %q cannot contain a \".\"", method, alias))
+	}
+
+	if subquery == nil {
+		panic(fmt.Errorf("QuerySet.%s: subquery %q cannot be nil", method, alias))
+	}
+
+	var sub = subquery.Clone()
+	if len(sub.internals.Fields) == 0 {
+		sub = sub.Select("*")
+	}
+
+	// a derived table should not be limited by default,
+	// only when a limit was explicitly set.
+	if sub.internals.Limit == MAX_DEFAULT_RESULTS {
+		sub.internals.Limit = 0
+	}
+
+	return qs.Clone(), sub
+}
+
+// resolveJoinField resolves the field to join on and the alias of its table.
+func (qs *QuerySet[T]) resolveJoinField(field string) (tableAlias string, f attrs.FieldDefinition, err error) {
+	var obj, _, fld, _, aliases, _, walkErr = internal.WalkFields(
+		qs.internals.Model.Object, field, qs.AliasGen,
+	)
+	if walkErr != nil {
+		return "", nil, walkErr
+	}
+
+	if len(aliases) > 0 {
+		return aliases[len(aliases)-1], fld, nil
+	}
+	return obj.FieldDefs().TableName(), fld, nil
+}
+
+// subqueryColumnName returns the name of the column which is selected
+// for the field or annotation of the queryset when it is used as a subquery.
+func (qs *QuerySet[T]) subqueryColumnName(name string) string {
+	if _, ok := qs.internals.Annotations.Get(name); ok {
+		return qs.AliasGen.GetFieldAlias(qs.internals.Model.TableName, name)
+	}
+
+	if field, ok := qs.internals.Model.Object.FieldDefs().Field(name); ok && field.ColumnName() != "" {
+		return field.ColumnName()
+	}
+
+	return name
+}
+
+// subqueryColumn resolves a reference to a column of a subquery which is joined to the query, I.E. `alias.Field`.
+func (i *QuerySetInternals) subqueryColumn(field string) (*expr.TableColumn, bool) {
+	var alias, name, ok = strings.Cut(field, ".")
+	if !ok || alias == "" || name == "" {
+		return nil, false
+	}
+
+	for _, join := range i.Joins {
+		if join.Subquery == nil || join.Table.Alias != alias {
+			continue
+		}
+
+		return &expr.TableColumn{
+			TableOrAlias: alias,
+			FieldAlias:   join.Subquery.subqueryColumnName(name),
+		}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,410 @@
+package queries
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Nigel2392/go-django-queries/internal"
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+	"github.com/Nigel2392/go-django-queries/src/expr"
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/pkg/errors"
+)
+
+// Page is a single page of results returned by [QuerySet.Paginate].
+//
+// Next and Previous are opaque cursors which can be passed to [QuerySet.Paginate]
+// to retrieve the next or previous page, they are empty if there is no such page.
+type Page[T attrs.Definer] struct {
+	Rows     Rows[T]
+	Next     string
+	Previous string
+}
+
+// HasNext returns true if there is a page after the current page.
+func (p *Page[T]) HasNext() bool {
+	return p.Next != ""
+}
+
+// HasPrevious returns true if there is a page before the current page.
+func (p *Page[T]) HasPrevious() bool {
+	return p.Previous != ""
+}
+
+// Paginate retrieves a single page of results using keyset (cursor) pagination.
+//
+// Instead of an offset, a seek predicate is derived from the ordering of the queryset
+// and the values of the last row of the previous page, allowing the database to use an
+// index instead of scanning and skipping all rows before the page.
+//
+// The primary key is added to the ordering to guarantee a stable order,
+// the ordering fields must be fields of the model and must be selected.
+//
+// An empty cursor retrieves the first page, the returned [Page] contains the cursors
+// for the next and previous pages. A cursor is only valid for a queryset with the same ordering.
+func (qs *QuerySet[T]) Paginate(cursor string, pageSize int) (*Page[T], error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("QuerySet.Paginate: page size must be greater than 0, got %d", pageSize)
+	}
+
+	var keys, err = qs.paginationKeys()
+	if err != nil {
+		return nil, errors.Wrap(err, "QuerySet.Paginate")
+	}
+
+	var cur = &pageCursor{}
+	if cursor != "" {
+		cur, err = decodePageCursor(cursor, keys)
+		if err != nil {
+			return nil, errors.Wrap(err, "QuerySet.Paginate")
+		}
+	}
+
+	// when paginating backwards the ordering is reversed,
+	// the rows are reversed again after they are retrieved.
+	var nqs = qs.Clone()
+	nqs.internals.OrderBy = make([]OrderBy, len(keys))
+	for i, key := range keys {
+		nqs.internals.OrderBy[i] = OrderBy{
+			Column: key.column,
+			Desc:   key.desc != cur.Previous,
+		}
+	}
+
+	if cursor != "" {
+		nqs = nqs.Filter(nqs.seekPredicate(keys, cur))
+	}
+
+	// retrieve one extra row to know if there are more rows
+	nqs.internals.Limit = pageSize + 1
+	nqs.internals.Offset = 0
+
+	rows, err := nqs.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var hasMore = len(rows) > pageSize
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+
+	if cur.Previous {
+		slices.Reverse(rows)
+	}
+
+	var page = &Page[T]{
+		Rows: rows,
+	}
+
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	var hasNext, hasPrevious = hasMore, cursor != ""
+	if cur.Previous {
+		hasNext, hasPrevious = true, hasMore
+	}
+
+	if hasNext {
+		page.Next, err = encodePageCursor(keys, rows[len(rows)-1].Object, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "QuerySet.Paginate")
+		}
+	}
+
+	if hasPrevious {
+		page.Previous, err = encodePageCursor(keys, rows[0].Object, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "QuerySet.Paginate")
+		}
+	}
+
+	return page, nil
+}
+
+type paginationKey struct {
+	name   string
+	column expr.TableColumn
+	desc   bool
+}
+
+// paginationKeys returns the ordering of the queryset with the primary key appended.
+func (qs *QuerySet[T]) paginationKeys() ([]paginationKey, error) {
+	var primary = qs.internals.Model.Primary
+	if primary == nil {
+		return nil, fmt.Errorf(
+			"cannot paginate %T without a primary key: %w",
+			qs.internals.Model.Object, query_errors.ErrNoUniqueKey,
+		)
+	}
+
+	var orderBy = qs.internals.OrderBy
+	if len(orderBy) == 0 {
+		orderBy = qs.compileOrderBy()
+	}
+
+	var (
+		defs       = qs.internals.Model.Object.FieldDefs()
+		keys       = make([]paginationKey, 0, len(orderBy)+1)
+		hasPrimary bool
+		desc       bool
+	)
+
+	for _, ord := range orderBy {
+		if ord.Column.FieldColumn == nil || ord.Column.TableOrAlias != qs.internals.Model.TableName {
+			var col = new(strings.Builder)
+			writeCol(col, &ord.Column)
+			return nil, fmt.Errorf(
+				"cannot paginate on %q, only fields of %T can be used: %w",
+				col.String(), qs.internals.Model.Object, query_errors.ErrNotImplemented,
+			)
+		}
+
+		var field, ok = defs.Field(ord.Column.FieldColumn.Name())
+		if !ok {
+			return nil, fmt.Errorf(
+				"cannot paginate on %q: %w",
+				ord.Column.FieldColumn.Name(), query_errors.ErrFieldNotFound,
+			)
+		}
+
+		keys = append(keys, paginationKey{
+			name:   field.Name(),
+			column: ord.Column,
+			desc:   ord.Desc,
+		})
+		desc = ord.Desc
+
+		// the primary key is unique, any
+		// ordering after it can be ignored
+		if field.Name() == primary.Name() {
+			hasPrimary = true
+			break
+		}
+	}
+
+	if !hasPrimary {
+		keys = append(keys, paginationKey{
+			name: primary.Name(),
+			column: expr.TableColumn{
+				TableOrAlias: qs.internals.Model.TableName,
+				FieldColumn:  primary,
+			},
+			desc: desc,
+		})
+	}
+
+	return keys, nil
+}
+
+// seekPredicate builds the condition to retrieve all rows after the cursor, I.E. for (a ASC, b DESC):
+//
+//	a > ? OR (a = ? AND b < ?)
+//
+// NULL values are handled according to where the database sorts them.
+func (qs *QuerySet[T]) seekPredicate(keys []paginationKey, cur *pageCursor) expr.Expression {
+	// SQLite, MySQL and MariaDB sort NULL values first in ascending order, PostgreSQL sorts them last.
+	var nullsFirst = true
+	var inf = qs.compiler.ExpressionInfo(ChangeObjectsType[T, attrs.Definer](qs), qs.internals)
+	if _, ok := inf.Driver.(*drivers.DriverPostgres); ok {
+		nullsFirst = false
+	}
+
+	var (
+		terms = make([]expr.Expression, 0, len(keys))
+		equal = make([]expr.Expression, 0, len(keys))
+	)
+
+	for i, key := range keys {
+		var (
+			value     = cur.values[i]
+			desc      = key.desc != cur.Previous
+			nullsLast = desc == nullsFirst
+			after     expr.Expression
+		)
+
+		switch {
+		case value == nil && !nullsLast:
+			after = expr.Q(key.name+"__isnull", false)
+		case value == nil:
+			// nothing is sorted after NULL values
+		case desc:
+			after = expr.Q(key.name+"__lt", value)
+		default:
+			after = expr.Q(key.name+"__gt", value)
+		}
+
+		if value != nil && nullsLast {
+			after = expr.Or(after, expr.Q(key.name+"__isnull", true))
+		}
+
+		if after != nil {
+			terms = append(terms, expr.And(
+				append(slices.Clone(equal), after)...,
+			))
+		}
+
+		if value == nil {
+			equal = append(equal, expr.Q(key.name+"__isnull", true))
+		} else {
+			equal = append(equal, expr.Q(key.name, value))
+		}
+	}
+
+	return expr.Or(terms...)
+}
+
+type pageCursor struct {
+	Previous bool          `json:"p,omitempty"`
+	Order    string        `json:"o"`
+	Values   []cursorValue `json:"v"`
+
+	values []any
+}
+
+type cursorValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+func paginationOrder(keys []paginationKey) string {
+	var names = make([]string, len(keys))
+	for i, key := range keys {
+		if key.desc {
+			names[i] = "-" + key.name
+		} else {
+			names[i] = key.name
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func encodePageCursor(keys []paginationKey, obj attrs.Definer, previous bool) (string, error) {
+	var (
+		defs = obj.FieldDefs()
+		cur  = pageCursor{
+			Previous: previous,
+			Order:    paginationOrder(keys),
+			Values:   make([]cursorValue, len(keys)),
+		}
+	)
+
+	for i, key := range keys {
+		var field, ok = defs.Field(key.name)
+		if !ok {
+			return "", fmt.Errorf("field %q not found in %T: %w", key.name, obj, query_errors.ErrFieldNotFound)
+		}
+
+		// nullable fields can be pointers, the cursor stores the value they point to
+		var fieldValue = field.GetValue()
+		if rv := reflect.ValueOf(fieldValue); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			if _, ok := fieldValue.(attrs.Definer); !ok {
+				fieldValue = rv.Elem().Interface()
+			}
+		}
+
+		var value, err = internal.DriverValue(fieldValue)
+		if err != nil {
+			return "", fmt.Errorf("cannot use %q in cursor: %w", key.name, err)
+		}
+
+		var typ string
+		switch value.(type) {
+		case nil:
+			cur.Values[i] = cursorValue{Type: "null"}
+			continue
+		case int64:
+			typ = "int"
+		case uint64:
+			typ = "uint"
+		case float64:
+			typ = "float"
+		case bool:
+			typ = "bool"
+		case string:
+			typ = "string"
+		case []byte:
+			typ = "bytes"
+		case time.Time:
+			typ = "time"
+		default:
+			return "", fmt.Errorf(
+				"cannot use %q of type %T in cursor: %w",
+				key.name, value, query_errors.ErrTypeMismatch,
+			)
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("cannot use %q in cursor: %w", key.name, err)
+		}
+
+		cur.Values[i] = cursorValue{Type: typ, Value: data}
+	}
+
+	var data, err = json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageCursor(cursor string, keys []paginationKey) (*pageCursor, error) {
+	var data, err = base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", query_errors.ErrInvalidCursor, err)
+	}
+
+	var cur = &pageCursor{}
+	if err = json.Unmarshal(data, cur); err != nil {
+		return nil, fmt.Errorf("%w: %v", query_errors.ErrInvalidCursor, err)
+	}
+
+	if cur.Order != paginationOrder(keys) || len(cur.Values) != len(keys) {
+		return nil, fmt.Errorf(
+			"%w: cursor was created for ordering %q, not %q",
+			query_errors.ErrInvalidCursor, cur.Order, paginationOrder(keys),
+		)
+	}
+
+	cur.values = make([]any, len(cur.Values))
+	for i, v := range cur.Values {
+		var dst any
+		switch v.Type {
+		case "null":
+			continue
+		case "int":
+			dst = new(int64)
+		case "uint":
+			dst = new(uint64)
+		case "float":
+			dst = new(float64)
+		case "bool":
+			dst = new(bool)
+		case "string":
+			dst = new(string)
+		case "bytes":
+			dst = new([]byte)
+		case "time":
+			dst = new(time.Time)
+		default:
+			return nil, fmt.Errorf("%w: unknown value type %q", query_errors.ErrInvalidCursor, v.Type)
+		}
+
+		if err = json.Unmarshal(v.Value, dst); err != nil {
+			return nil, fmt.Errorf("%w: %v", query_errors.ErrInvalidCursor, err)
+		}
+
+		cur.values[i] = reflect.ValueOf(dst).Elem().Interface()
+	}
+
+	return cur, nil
+}
@@ -0,0 +1,475 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/internal"
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/Nigel2392/go-django/src/forms/fields"
+	"github.com/pkg/errors"
+)
+
+// Prefetch describes a relation path which should be loaded
+// in a separate query after the main query has been executed.
+//
+// The path is a dot-separated list of relation field names, I.E. "Todos" or "Todos.Tags".
+//
+// The QuerySet is optional and is used to query the objects of the last relation in the path.
+// It can be used to filter, order or select related fields of the prefetched objects.
+//
+// See [QuerySet.Prefetch] for more details.
+type Prefetch struct {
+	Path     string
+	QuerySet *GenericQuerySet
+}
+
+// Prefetch is used to load reverse foreign key and many-to-many relations
+// with a separate query per relation path instead of JOINing them into the main query.
+//
+// Each path can either be a string or a [Prefetch] object, the latter allows for
+// a custom QuerySet to be used when querying the related objects.
+//
+// After the main query is executed in [QuerySet.All], a `SELECT ... WHERE fk IN (...)`
+// query is executed for each path, and the results are set on the parent objects
+// using [setRelatedObjects], the same way it is done for JOINed relations.
+//
+// Many-to-many relations first query the through model to find the target keys,
+// after which the targets are queried with an IN query.
+//
+// Nested paths are supported, intermediate relations which are not prefetched
+// explicitly will be prefetched automatically, forward relations in the path
+// (foreign keys, one-to-one) need to be selected in the main query with [QuerySet.Select].
+func (qs *QuerySet[T]) Prefetch(paths ...any) *QuerySet[T] {
+	var nqs = qs.Clone()
+	for _, path := range paths {
+		switch p := path.(type) {
+		case string:
+			nqs.internals.Prefetch = append(nqs.internals.Prefetch, Prefetch{
+				Path: p,
+			})
+		case Prefetch:
+			nqs.internals.Prefetch = append(nqs.internals.Prefetch, p)
+		case *Prefetch:
+			nqs.internals.Prefetch = append(nqs.internals.Prefetch, *p)
+		default:
+			panic(fmt.Errorf(
+				"QuerySet.Prefetch: unsupported type %T, expected string or Prefetch: %w",
+				path, query_errors.ErrTypeMismatch,
+			))
+		}
+	}
+	return nqs
+}
+
+// prefetchRelated executes the prefetch queries for the rows returned by [QuerySet.All].
+//
+// Paths are executed in order of depth, so that the related objects
+// of a path are available when a deeper path is being prefetched.
+func (qs *QuerySet[T]) prefetchRelated(rows Rows[T]) error {
+	var objects = make([]attrs.Definer, 0, len(rows))
+	for _, row := range rows {
+		objects = append(objects, row.Object)
+	}
+
+	var prefetches = slices.Clone(qs.internals.Prefetch)
+	slices.SortStableFunc(prefetches, func(a, b Prefetch) int {
+		return strings.Count(a.Path, ".") - strings.Count(b.Path, ".")
+	})
+
+	var done = make(map[string][]attrs.Definer)
+	for _, prefetch := range prefetches {
+		var (
+			parents = objects
+			parts   = strings.Split(prefetch.Path, ".")
+		)
+
+		for i, part := range parts {
+			var (
+				path   = strings.Join(parts[:i+1], ".")
+				isLast = i == len(parts)-1
+			)
+
+			if related, ok := done[path]; ok {
+				if isLast && prefetch.QuerySet != nil {
+					return fmt.Errorf(
+						"prefetch %q: path was already prefetched, a custom QuerySet cannot be applied",
+						prefetch.Path,
+					)
+				}
+				parents = related
+				continue
+			}
+
+			var inner *GenericQuerySet
+			if isLast {
+				inner = prefetch.QuerySet
+			}
+
+			var related, err = prefetchRelation(
+				qs.Context(), parents, part, inner,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "prefetch %q", prefetch.Path)
+			}
+
+			done[path] = related
+			parents = related
+		}
+	}
+
+	return nil
+}
+
+// prefetchRelation loads the objects for the relation with the given
+// field name for all parents and sets them on the parent objects.
+//
+// It returns all the related objects which were found, these are used
+// as the parents for the next part of a nested path.
+func prefetchRelation(ctx context.Context, parents []attrs.Definer, name string, inner *GenericQuerySet) ([]attrs.Definer, error) {
+	if len(parents) == 0 {
+		return nil, nil
+	}
+
+	var field, ok = parents[0].FieldDefs().Field(name)
+	if !ok {
+		return nil, fmt.Errorf(
+			"field %q not found in %T: %w",
+			name, parents[0], query_errors.ErrFieldNotFound,
+		)
+	}
+
+	var rel = field.Rel()
+	if rel == nil {
+		return nil, fmt.Errorf(
+			"field %q in %T is not a relation", name, parents[0],
+		)
+	}
+
+	switch rel.Type() {
+	case attrs.RelOneToMany:
+		return prefetchReverseForeignKey(ctx, parents, field, rel, inner)
+	case attrs.RelManyToMany:
+		return prefetchManyToMany(ctx, parents, field, rel, inner)
+	}
+
+	if inner != nil {
+		return nil, fmt.Errorf(
+			"cannot use a custom QuerySet for %s relation %q in %T: %w",
+			rel.Type(), name, parents[0], query_errors.ErrNotImplemented,
+		)
+	}
+
+	// forward relations have to be selected in the main
+	// query, we only need the objects for the next part of the path.
+	var related = make([]attrs.Definer, 0, len(parents))
+	for _, parent := range parents {
+		var defs = parent.FieldDefs()
+		var f, ok = defs.Field(name)
+		if !ok {
+			continue
+		}
+		related = append(related, prefetchObjects(f.GetValue())...)
+	}
+	return related, nil
+}
+
+func prefetchReverseForeignKey(ctx context.Context, parents []attrs.Definer, field attrs.Field, rel attrs.Relation, inner *GenericQuerySet) ([]attrs.Definer, error) {
+	var targetField = rel.Field()
+	if targetField == nil {
+		return nil, fmt.Errorf(
+			"reverse relation %q in %T has no target field: %w",
+			field.Name(), parents[0], query_errors.ErrFieldNotFound,
+		)
+	}
+
+	var (
+		parentKey  = prefetchKeyField(parents[0].FieldDefs(), field)
+		keys       = prefetchKeys(parents, parentKey.Name())
+		relatedMap = make(map[any][]Relation, len(keys))
+	)
+
+	if len(keys) > 0 {
+		var qs, err = prefetchQuerySet(ctx, rel.Model(), inner)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := qs.
+			Filter(fmt.Sprintf("%s__in", targetField.Name()), keys...).
+			All()
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "failed to query related objects for %q", field.Name(),
+			)
+		}
+
+		for _, row := range rows {
+			var f, ok = row.Object.FieldDefs().Field(targetField.Name())
+			if !ok {
+				return nil, fmt.Errorf(
+					"field %q not found in %T: %w",
+					targetField.Name(), row.Object, query_errors.ErrFieldNotFound,
+				)
+			}
+
+			var key = prefetchKeyValue(f.GetValue(), parentKey.Name())
+			if key == nil {
+				return nil, fmt.Errorf(
+					"field %q of %T was not selected, it is required to set the related objects",
+					targetField.Name(), row.Object,
+				)
+			}
+
+			var uniqueValue, _ = GetUniqueKey(row.Object)
+			relatedMap[key] = append(relatedMap[key], &baseRelation{
+				uniqueValue: uniqueValue,
+				object:      row.Object,
+			})
+		}
+	}
+
+	return setPrefetchedObjects(parents, field.Name(), attrs.RelOneToMany, parentKey.Name(), relatedMap), nil
+}
+
+func prefetchManyToMany(ctx context.Context, parents []attrs.Definer, field attrs.Field, rel attrs.Relation, inner *GenericQuerySet) ([]attrs.Definer, error) {
+	var through = rel.Through()
+	if through == nil {
+		return nil, fmt.Errorf(
+			"many-to-many relation %q in %T does not have a through model",
+			field.Name(), parents[0],
+		)
+	}
+
+	var (
+		throughObject = newThroughProxy(through)
+		target        = internal.NewObjectFromIface(rel.Model())
+		targetKey     = getTargetField(throughObject.targetField, target.FieldDefs())
+		parentKey     = prefetchKeyField(parents[0].FieldDefs(), field)
+		keys          = prefetchKeys(parents, parentKey.Name())
+		relatedMap    = make(map[any][]Relation, len(keys))
+	)
+
+	if len(keys) > 0 {
+		var throughQs = GetQuerySet(internal.NewObjectFromIface(throughObject.object)).
+			WithContext(ctx).
+			Filter(fmt.Sprintf("%s__in", throughObject.sourceField.Name()), keys...)
+		throughQs.internals.Limit = 0
+
+		var throughRows, err = throughQs.All()
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "failed to query through objects for %q", field.Name(),
+			)
+		}
+
+		// map the target keys to the through objects,
+		// a target can be related to multiple parents.
+		var (
+			targetKeys = make([]any, 0, len(throughRows))
+			throughMap = make(map[any][]attrs.Definer, len(throughRows))
+		)
+		for _, row := range throughRows {
+			var defs = row.Object.FieldDefs()
+			var targetKeyValue = prefetchKeyValue(
+				defs.Get(throughObject.targetField.Name()), targetKey.Name(),
+			)
+			if targetKeyValue == nil {
+				continue
+			}
+
+			if _, ok := throughMap[targetKeyValue]; !ok {
+				targetKeys = append(targetKeys, targetKeyValue)
+			}
+			throughMap[targetKeyValue] = append(throughMap[targetKeyValue], row.Object)
+		}
+
+		if len(targetKeys) > 0 {
+			qs, err := prefetchQuerySet(ctx, target, inner)
+			if err != nil {
+				return nil, err
+			}
+
+			rows, err := qs.
+				Filter(fmt.Sprintf("%s__in", targetKey.Name()), targetKeys...).
+				All()
+			if err != nil {
+				return nil, errors.Wrapf(
+					err, "failed to query related objects for %q", field.Name(),
+				)
+			}
+
+			for _, row := range rows {
+				var targetKeyValue = prefetchKeyValue(
+					row.Object.FieldDefs().Get(targetKey.Name()), targetKey.Name(),
+				)
+
+				var uniqueValue, _ = GetUniqueKey(row.Object)
+				for _, throughObj := range throughMap[targetKeyValue] {
+					var key = prefetchKeyValue(
+						throughObj.FieldDefs().Get(throughObject.sourceField.Name()), parentKey.Name(),
+					)
+					relatedMap[key] = append(relatedMap[key], &baseRelation{
+						uniqueValue: uniqueValue,
+						object:      row.Object,
+						through:     throughObj,
+					})
+				}
+			}
+		}
+	}
+
+	return setPrefetchedObjects(parents, field.Name(), attrs.RelManyToMany, parentKey.Name(), relatedMap), nil
+}
+
+// prefetchQuerySet returns the queryset used to retrieve the prefetched objects.
+//
+// If a custom queryset is provided, it is checked to be for the correct model.
+// The default limit is removed from the queryset, all related objects should be returned.
+func prefetchQuerySet(ctx context.Context, target attrs.Definer, inner *GenericQuerySet) (*GenericQuerySet, error) {
+	var qs *GenericQuerySet
+	if inner != nil {
+		if reflect.TypeOf(inner.internals.Model.Object) != reflect.TypeOf(target) {
+			return nil, fmt.Errorf(
+				"QuerySet for %T cannot be used to prefetch %T: %w",
+				inner.internals.Model.Object, target, query_errors.ErrTypeMismatch,
+			)
+		}
+		qs = inner.Clone()
+	} else {
+		qs = GetQuerySet(internal.NewObjectFromIface(target))
+	}
+
+	if qs.internals.Limit == MAX_DEFAULT_RESULTS {
+		qs.internals.Limit = 0
+	}
+
+	return qs.WithContext(ctx), nil
+}
+
+// prefetchKeyField returns the field of the parent model which holds
+// the value the related objects point to.
+//
+// This is the field which has the same column as the relation field,
+// the primary field is returned if no such field exists.
+func prefetchKeyField(defs attrs.Definitions, relField attrs.Field) attrs.Field {
+	var column = relField.ColumnName()
+	for _, f := range defs.Fields() {
+		if f.Rel() == nil && f.ColumnName() == column {
+			return f
+		}
+	}
+	return defs.Primary()
+}
+
+// prefetchKeys returns the unique non-zero values of the given field for all objects.
+func prefetchKeys(objects []attrs.Definer, fieldName string) []any {
+	var (
+		keys = make([]any, 0, len(objects))
+		seen = make(map[any]struct{}, len(objects))
+	)
+	for _, obj := range objects {
+		var value = obj.FieldDefs().Get(fieldName)
+		if value == nil || fields.IsZero(value) {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		keys = append(keys, value)
+	}
+	return keys
+}
+
+// prefetchKeyValue returns the key which a relation value points to.
+//
+// Relation fields usually hold (a stub of) the related object,
+// in that case the value of the key field of that object is returned.
+func prefetchKeyValue(value any, keyField string) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case RelationValue:
+		var obj = v.GetValue()
+		if obj == nil {
+			return nil
+		}
+		return obj.FieldDefs().Get(keyField)
+	case ThroughRelationValue:
+		var obj, _ = v.GetValue()
+		if obj == nil {
+			return nil
+		}
+		return obj.FieldDefs().Get(keyField)
+	case attrs.Definer:
+		if isNil(reflect.ValueOf(v)) {
+			return nil
+		}
+		return v.FieldDefs().Get(keyField)
+	}
+	return value
+}
+
+// prefetchObjects returns the related objects held by a relation field's value.
+func prefetchObjects(value any) []attrs.Definer {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case RelationValue:
+		if obj := v.GetValue(); obj != nil {
+			return []attrs.Definer{obj}
+		}
+	case ThroughRelationValue:
+		if obj, _ := v.GetValue(); obj != nil {
+			return []attrs.Definer{obj}
+		}
+	case MultiRelationValue:
+		return v.GetValues()
+	case MultiThroughRelationValue:
+		var rels = v.GetValues()
+		var objs = make([]attrs.Definer, 0, len(rels))
+		for _, rel := range rels {
+			objs = append(objs, rel.Model())
+		}
+		return objs
+	case attrs.Definer:
+		if !isNil(reflect.ValueOf(v)) {
+			return []attrs.Definer{v}
+		}
+	case []attrs.Definer:
+		return v
+	}
+	return nil
+}
+
+// setPrefetchedObjects sets the related objects on each parent and
+// returns all related objects in order of the parents.
+func setPrefetchedObjects(parents []attrs.Definer, relName string, relTyp attrs.RelationType, keyField string, relatedMap map[any][]Relation) []attrs.Definer {
+	var (
+		related = make([]attrs.Definer, 0, len(relatedMap))
+		seen    = make(map[attrs.Definer]struct{}, len(relatedMap))
+	)
+	for _, parent := range parents {
+		var relatedObjects = relatedMap[parent.FieldDefs().Get(keyField)]
+		if relatedObjects == nil {
+			relatedObjects = make([]Relation, 0)
+		}
+
+		setRelatedObjects(relName, relTyp, parent, relatedObjects)
+
+		for _, rel := range relatedObjects {
+			var obj = rel.Model()
+			if _, ok := seen[obj]; ok {
+				continue
+			}
+			seen[obj] = struct{}{}
+			related = append(related, obj)
+		}
+	}
+	return related
+}
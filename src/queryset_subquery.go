@@ -11,16 +11,26 @@ import (
 //go:linkname newFunc github.com/Nigel2392/go-django-queries/src/expr.newFunc
 func newFunc(funcLookup string, value []any, expr ...any) *expr.Function
 
-var _ expr.Expression = (*subqueryExpr)(nil)
+var _ expr.SubqueryExpression = (*subqueryExpr)(nil)
 
 type subqueryExpr struct {
 	field expr.Expression
+	qs    *GenericQuerySet
+	query func(qs *GenericQuerySet) QueryInfo
 	q     QueryInfo
 	op    string
 	not   bool
 	used  bool
 }
 
+// compile compiles the subquery, outer is the expression info of the
+// outer query and is used to resolve [expr.OuterRef] expressions.
+func (s *subqueryExpr) compile(outer *expr.ExpressionInfo) QueryInfo {
+	var qs = s.qs.Clone()
+	qs.internals.OuterInfo = outer
+	return s.query(qs)
+}
+
 func (s *subqueryExpr) SQL(sb *strings.Builder) []any {
 	var written bool
 	var args = make([]any, 0)
@@ -47,6 +57,10 @@ func (s *subqueryExpr) SQL(sb *strings.Builder) []any {
 		written = true
 	}
 
+	if s.q == nil {
+		s.q = s.compile(nil)
+	}
+
 	var sql = s.q.SQL()
 	if sql != "" {
 		if written {
@@ -62,8 +76,25 @@ func (s *subqueryExpr) SQL(sb *strings.Builder) []any {
 	return args
 }
 
+// SubquerySQL writes the subquery without parentheses,
+// subqueries with an operator or field are written like [subqueryExpr.SQL].
+func (s *subqueryExpr) SubquerySQL(sb *strings.Builder) []any {
+	if s.field != nil || s.not || s.op != "" {
+		return s.SQL(sb)
+	}
+
+	if s.q == nil {
+		s.q = s.compile(nil)
+	}
+
+	sb.WriteString(s.q.SQL())
+	return s.q.Args()
+}
+
 func (s *subqueryExpr) Clone() expr.Expression {
 	return &subqueryExpr{
+		qs:    s.qs,
+		query: s.query,
 		q:     s.q,
 		not:   s.not,
 		used:  s.used,
@@ -84,32 +115,40 @@ func (s *subqueryExpr) Resolve(inf *expr.ExpressionInfo) expr.Expression {
 		nE.field = nE.field.Resolve(inf)
 	}
 
+	nE.q = nE.compile(inf)
 	return nE
 }
 
 func Subquery(qs *GenericQuerySet) expr.Expression {
+	qs = qs.Clone()
 	if qs.internals.Limit == MAX_DEFAULT_RESULTS {
 		qs.internals.Limit = 0
 	}
 
-	q := qs.queryAll()
 	return &subqueryExpr{
-		q: q,
+		qs: qs,
+		query: func(qs *GenericQuerySet) QueryInfo {
+			return qs.queryAll()
+		},
 	}
 }
 
 func SubqueryCount(qs *GenericQuerySet) *subqueryExpr {
-	q := qs.queryCount()
 	return &subqueryExpr{
-		q:  q,
+		qs: qs.Clone(),
+		query: func(qs *GenericQuerySet) QueryInfo {
+			return qs.queryCount()
+		},
 		op: "COUNT",
 	}
 }
 
 func SubqueryExists(qs *GenericQuerySet) expr.Expression {
-	q := qs.queryAll()
 	return &subqueryExpr{
-		q:  q,
+		qs: qs.Clone(),
+		query: func(qs *GenericQuerySet) QueryInfo {
+			return qs.queryAll()
+		},
 		op: "EXISTS",
 	}
 }
@@ -16,8 +16,13 @@ type transactionContextKey struct{}
 type transactionContextValue struct {
 	Transaction  drivers.Transaction
 	DatabaseName string
+
+	// Parent holds the transactions which were stored in the context before this one,
+	// a context can hold a transaction for each database, see [RunInMultiTransaction].
+	Parent *transactionContextValue
 }
 
+// transactionFromContext returns the transaction which was last stored in the context.
 func transactionFromContext(ctx context.Context) (tx drivers.Transaction, databaseName string, ok bool) {
 	t, ok := ctx.Value(transactionContextKey{}).(*transactionContextValue)
 	if !ok {
@@ -26,21 +31,90 @@ func transactionFromContext(ctx context.Context) (tx drivers.Transaction, databa
 	return t.Transaction, t.DatabaseName, t.Transaction != nil
 }
 
+// transactionForDatabase returns the transaction for the given database from the context.
+func transactionForDatabase(ctx context.Context, databaseName string) (tx drivers.Transaction, ok bool) {
+	t, _ := ctx.Value(transactionContextKey{}).(*transactionContextValue)
+	for ; t != nil; t = t.Parent {
+		if t.DatabaseName == databaseName && t.Transaction != nil {
+			return t.Transaction, true
+		}
+	}
+	return nil, false
+}
+
 func transactionToContext(ctx context.Context, tx drivers.Transaction, dbName string) context.Context {
 	if tx == nil {
 		panic("transactionToContext: transaction is nil")
 	}
+	var parent, _ = ctx.Value(transactionContextKey{}).(*transactionContextValue)
 	return context.WithValue(ctx, transactionContextKey{}, &transactionContextValue{
 		Transaction:  tx,
 		DatabaseName: dbName,
+		Parent:       parent,
 	})
 }
 
+// OnCommit registers a function which is run after the transaction in the context is committed,
+// I.E. to send an email only when the changes were saved:
+//
+//	queries.OnCommit(ctx, func() {
+//		sendWelcomeEmail(user)
+//	})
+//
+// If the transaction is rolled back, the function is not run.
+//
+// The functions of a nested transaction are moved to the transaction it is nested in when it is committed,
+// they are only run when the outermost transaction is committed.
+//
+// If the context holds transactions for multiple databases, see [RunInMultiTransaction],
+// the function is registered on the transaction which was started last.
+//
+// If the context has no transaction, the function is run immediately.
+func OnCommit(ctx context.Context, fn func()) {
+	var hooks, ok = transactionHooksFromContext(ctx)
+	if !ok {
+		fn()
+		return
+	}
+	hooks.add(fn, nil)
+}
+
+// OnRollback registers a function which is run after the transaction in the context is rolled back.
+//
+// The functions of a nested transaction are run when the nested transaction is rolled back,
+// or when the transaction it is nested in is rolled back after it was committed.
+//
+// The functions are also run when committing the transaction fails,
+// or when the transaction was already rolled back because its context was canceled.
+//
+// If the context has no transaction, nothing can be rolled back and the function is never run.
+func OnRollback(ctx context.Context, fn func()) {
+	var hooks, ok = transactionHooksFromContext(ctx)
+	if !ok {
+		return
+	}
+	hooks.add(nil, fn)
+}
+
+func transactionHooksFromContext(ctx context.Context) (*transactionHooks, bool) {
+	var tx, _, ok = transactionFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	hooked, ok := tx.(hookedTransaction)
+	if !ok {
+		return nil, false
+	}
+
+	return hooked.transactionHooks(), true
+}
+
 // StartTransaction starts a new transaction for the given database.
 //
-// If a transaction already exists in the context, it will return a no-op transaction,
-// meaning that Rollback and Commit will do nothing, it is assumed that the transaction
-// is managed by a higher-level function in the call stack.
+// If a transaction already exists in the context, a nested transaction is started with a `SAVEPOINT`.
+// Committing the nested transaction releases the savepoint, rolling it back only undoes the changes
+// made inside of it - the transaction from the context stays in control of the final commit.
 //
 // If the database name is not provided, it will use the default database name from the compiler.
 // If the database name is provided, it will use that database name to start the transaction.
@@ -52,22 +126,50 @@ func transactionToContext(ctx context.Context, tx drivers.Transaction, dbName st
 // transaction is stored in the context - a queryset will automatically use the transaction
 // from the context if it exists when using [QuerySet.WithContext].
 func StartTransaction(ctx context.Context, database ...string) (context.Context, DatabaseSpecificTransaction, error) {
+	return StartTransactionWithOptions(ctx, nil, database...)
+}
+
+// StartTransactionWithOptions starts a new transaction for the given database with the given options,
+// I.E. to start a transaction with the `SERIALIZABLE` isolation level:
+//
+//	var ctx, tx, err = queries.StartTransactionWithOptions(ctx, &drivers.TxOptions{
+//		Isolation: sql.LevelSerializable,
+//	})
+//
+// If the options are nil, the default options of the database are used.
+//
+// A savepoint uses the options of the transaction it is nested in, if the context already has a transaction
+// and the options are not nil, [query_errors.ErrTransactionStarted] is returned instead of nesting a savepoint.
+//
+// See [StartTransaction] for more details.
+func StartTransactionWithOptions(ctx context.Context, opts *drivers.TxOptions, database ...string) (context.Context, DatabaseSpecificTransaction, error) {
 	var (
-		databaseName   = getDatabaseName(nil, database...)
-		tx, dbName, ok = transactionFromContext(ctx)
-		err            error
+		databaseName = getDatabaseName(nil, database...)
+		tx, ok       = transactionForDatabase(ctx, databaseName)
+		err          error
 	)
 
-	// If the context already has a transaction, use it.
-	if ok && (dbName == "" || dbName == databaseName) {
-		// return a null transaction when there is already a transaction in the context
-		// this will make sure that RollBack and Commit are no-ops
-		return ctx, &dbSpecificTransaction{&nullTransaction{tx}, databaseName}, nil
+	// If the context already has a transaction, nest a savepoint in it.
+	if ok {
+		if opts != nil {
+			return ctx, nil, errors.Wrap(
+				query_errors.ErrTransactionStarted,
+				"StartTransaction: options cannot be applied to a nested transaction",
+			)
+		}
+
+		var savepoint, err = newSavepointTransaction(ctx, tx)
+		if err != nil {
+			return ctx, nil, errors.Wrap(err, "StartTransaction: failed to start nested transaction")
+		}
+
+		ctx = transactionToContext(ctx, savepoint, databaseName)
+		return ctx, &dbSpecificTransaction{savepoint, databaseName}, nil
 	}
 
 	// Otherwise, start a new transaction.
 	var compiler = Compiler(databaseName)
-	tx, err = compiler.StartTransaction(ctx)
+	tx, err = compiler.StartTransaction(ctx, opts)
 	if err != nil {
 		return ctx, nil, errors.Wrap(err, "StartTransaction: failed to start transaction")
 	}
@@ -76,6 +178,22 @@ func StartTransaction(ctx context.Context, database ...string) (context.Context,
 	return ctx, &dbSpecificTransaction{tx, databaseName}, nil
 }
 
+// GetOrCreateTransaction returns the transaction for the given database from the context,
+// or starts a new transaction if the context does not have one.
+//
+// Unlike [StartTransaction], no savepoint is created if the context already has a transaction:
+// the returned transaction is a no-op for Commit and Rollback, the transaction from the context stays in control.
+//
+// It is used for the transactions started by the library itself, I.E. when saving a model,
+// these do not have to be rolled back on their own and should not cost any extra queries.
+func GetOrCreateTransaction(ctx context.Context, database ...string) (context.Context, DatabaseSpecificTransaction, error) {
+	var databaseName = getDatabaseName(nil, database...)
+	if tx, ok := transactionForDatabase(ctx, databaseName); ok {
+		return ctx, &dbSpecificTransaction{&nullTransaction{tx}, databaseName}, nil
+	}
+	return StartTransaction(ctx, database...)
+}
+
 // RunInTransaction runs the given function in a transaction.
 //
 // The function should return a boolean indicating whether the transaction should be committed or rolled back.
@@ -85,12 +203,27 @@ func StartTransaction(ctx context.Context, database ...string) (context.Context,
 // to execute queries within the transaction.
 //
 // If the function panics, the transaction will be rolled back and the panic will be recovered.
+//
+// If the context already has a transaction, the function is run in a nested transaction,
+// see [StartTransaction]. Rolling back a nested transaction only undoes the changes made by the function.
 func RunInTransaction[T attrs.Definer](c context.Context, fn func(ctx context.Context, NewQuerySet ObjectsFunc[T]) (commit bool, err error), database ...string) error {
+	return RunInTransactionWithOptions(c, nil, fn, database...)
+}
+
+// RunInTransactionWithOptions runs the given function in a transaction which is started with the given options,
+// I.E. to run the function in a read-only transaction:
+//
+//	var err = queries.RunInTransactionWithOptions(ctx, &drivers.TxOptions{ReadOnly: true}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*Todo]) (bool, error) {
+//		...
+//	})
+//
+// See [RunInTransaction] and [StartTransactionWithOptions] for more details.
+func RunInTransactionWithOptions[T attrs.Definer](c context.Context, opts *drivers.TxOptions, fn func(ctx context.Context, NewQuerySet ObjectsFunc[T]) (commit bool, err error), database ...string) error {
 	var panicFromNewQuerySet error
 	var comitted bool
 
 	// If the context already has a transaction, use it.
-	var ctx, transaction, err = StartTransaction(c, database...)
+	var ctx, transaction, err = StartTransactionWithOptions(c, opts, database...)
 	if err != nil {
 		return errors.Wrap(err, "RunInTransaction: failed to start transaction")
 	}
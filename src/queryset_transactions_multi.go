@@ -0,0 +1,157 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/Nigel2392/go-django/src/core/logger"
+	"github.com/pkg/errors"
+)
+
+// MultiTransactionError is returned by [RunInMultiTransaction] when one of the transactions fails to commit.
+//
+// The transactions are committed in order, the transactions before the failed database
+// were committed and cannot be rolled back anymore.
+type MultiTransactionError struct {
+	// Database is the database of which the transaction failed to commit.
+	Database string
+
+	// Committed holds the databases of which the transaction was committed.
+	Committed []string
+
+	// RolledBack holds the databases of which the transaction was rolled back,
+	// this includes the database which failed to commit.
+	RolledBack []string
+
+	// Err is the error returned when committing the transaction.
+	Err error
+}
+
+func (e *MultiTransactionError) Error() string {
+	return fmt.Sprintf(
+		"RunInMultiTransaction: failed to commit transaction for %q (committed: [%s], rolled back: [%s]): %v",
+		e.Database, strings.Join(e.Committed, ", "), strings.Join(e.RolledBack, ", "), e.Err,
+	)
+}
+
+func (e *MultiTransactionError) Unwrap() error {
+	return e.Err
+}
+
+// RunInMultiTransaction runs the given function in a transaction for each of the given databases.
+//
+// The querysets created with the NewQuerySet function, or bound to the context with [QuerySet.WithContext],
+// use the transaction of their database. Creating a queryset for a database which is not part of
+// the given databases panics with [query_errors.ErrCrossDatabaseTransaction].
+//
+//	var err = queries.RunInMultiTransaction(ctx, []string{"default", "audit"}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[attrs.Definer]) (bool, error) {
+//		if _, err := NewQuerySet(&Order{}).Create(order); err != nil {
+//			return false, err
+//		}
+//		_, err := NewQuerySet(&AuditLog{}).Create(log)
+//		return err == nil, err
+//	})
+//
+// The function should return a boolean indicating whether the transactions should be committed or rolled back,
+// if the function returns an error or panics all transactions are rolled back.
+//
+// The transactions are committed one by one in the order of the databases, this is a best-effort two-phase commit:
+// if a transaction fails to commit the remaining transactions are rolled back, the transactions which were
+// already committed stay committed. A [*MultiTransactionError] is returned which reports the databases which were committed.
+//
+// If the context already has a transaction for one of the databases, a nested transaction is started for it, see [StartTransaction].
+func RunInMultiTransaction(c context.Context, databases []string, fn func(ctx context.Context, NewQuerySet ObjectsFunc[attrs.Definer]) (commit bool, err error)) (err error) {
+	if len(databases) == 0 {
+		return errors.New("RunInMultiTransaction: no databases provided")
+	}
+
+	for i, database := range databases {
+		if slices.Contains(databases[:i], database) {
+			return fmt.Errorf("RunInMultiTransaction: database %q provided more than once", database)
+		}
+	}
+
+	var ctx = c
+	var transactions = make([]DatabaseSpecificTransaction, 0, len(databases))
+	var rollback = func(transactions []DatabaseSpecificTransaction) []string {
+		var rolledBack = make([]string, 0, len(transactions))
+		for i := len(transactions) - 1; i >= 0; i-- {
+			if err := transactions[i].Rollback(); err != nil {
+				logger.Errorf("RunInMultiTransaction: failed to rollback transaction for %q: %v", transactions[i].DatabaseName(), err)
+			}
+			rolledBack = append(rolledBack, transactions[i].DatabaseName())
+		}
+		slices.Reverse(rolledBack)
+		return rolledBack
+	}
+
+	for _, database := range databases {
+		var transaction DatabaseSpecificTransaction
+		ctx, transaction, err = StartTransaction(ctx, database)
+		if err != nil {
+			rollback(transactions)
+			return errors.Wrapf(err, "RunInMultiTransaction: failed to start transaction for %q", database)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	var newQuerySetFunc = func(model attrs.Definer) *QuerySet[attrs.Definer] {
+		var qs = GetQuerySet(model)
+
+		// a queryset for a database without a transaction cannot be used
+		var databaseName = qs.compiler.DatabaseName()
+		if !slices.Contains(databases, databaseName) {
+			panic(fmt.Errorf(
+				"RunInMultiTransaction, %q not in [%s]: %w",
+				databaseName, strings.Join(databases, ", "),
+				query_errors.ErrCrossDatabaseTransaction,
+			))
+		}
+
+		return qs.WithContext(ctx)
+	}
+
+	var finished bool
+	defer func() {
+		if rec := recover(); rec != nil {
+			logger.Errorf("RunInMultiTransaction: panic recovered: %v", rec)
+			if recErr, ok := rec.(error); ok {
+				err = fmt.Errorf("RunInMultiTransaction: panic recovered: %w", recErr)
+			} else {
+				err = fmt.Errorf("RunInMultiTransaction: panic recovered: %v", rec)
+			}
+		}
+
+		if !finished {
+			rollback(transactions)
+		}
+	}()
+
+	commit, err := fn(ctx, newQuerySetFunc)
+	if err != nil {
+		return errors.Wrap(err, "RunInMultiTransaction: function returned an error")
+	}
+
+	if !commit {
+		return nil
+	}
+
+	finished = true
+	for i, transaction := range transactions {
+		if err := transaction.Commit(); err != nil {
+			// the transaction which failed to commit is already rolled back
+			return &MultiTransactionError{
+				Database:   transaction.DatabaseName(),
+				Committed:  slices.Clone(databases[:i]),
+				RolledBack: append([]string{transaction.DatabaseName()}, rollback(transactions[i+1:])...),
+				Err:        err,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package queries
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Nigel2392/go-django-queries/src/drivers"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+	"github.com/Nigel2392/go-django/src/core/logger"
+)
+
+const (
+	// The default maximum number of times the function passed to [RunInTransactionWithRetry] is run.
+	DEFAULT_TRANSACTION_ATTEMPTS = 3
+
+	// The default delay before the first retry of [RunInTransactionWithRetry],
+	// the delay is doubled for each following retry.
+	DEFAULT_TRANSACTION_BACKOFF = 10 * time.Millisecond
+)
+
+// RetryOptions holds the options for [RunInTransactionWithRetry].
+//
+// The zero value uses the defaults for all options.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of times the function is run,
+	// [DEFAULT_TRANSACTION_ATTEMPTS] is used if it is zero or less.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, it is doubled for each following retry.
+	//
+	// A random jitter of up to half the delay is added to each delay.
+	// [DEFAULT_TRANSACTION_BACKOFF] is used if it is zero or less.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between two attempts, the delay is not capped if it is zero or less.
+	MaxBackoff time.Duration
+
+	// TxOptions are the options used to start each transaction, see [StartTransactionWithOptions].
+	//
+	// They must be nil if the context already has a transaction.
+	TxOptions *drivers.TxOptions
+
+	// IsRetryable reports whether the transaction should be retried after the error,
+	// [drivers.IsRetryable] is used if it is nil.
+	IsRetryable func(err error) bool
+}
+
+type transactionAttemptContextKey struct{}
+
+// TransactionAttempt returns the number of the current attempt of [RunInTransactionWithRetry],
+// starting at 1 for the first attempt.
+//
+// It returns 0 if the context was not created by [RunInTransactionWithRetry].
+func TransactionAttempt(ctx context.Context) int {
+	var attempt, _ = ctx.Value(transactionAttemptContextKey{}).(int)
+	return attempt
+}
+
+// RunInTransactionWithRetry runs the given function in a transaction, see [RunInTransaction].
+//
+// If the transaction fails with an error which can be retried, I.E. a serialization failure or a deadlock,
+// the transaction is rolled back and the function is run again in a new transaction after a backoff delay.
+// The function must be safe to run more than once, the current attempt can be retrieved with [TransactionAttempt].
+//
+//	var err = queries.RunInTransactionWithRetry(ctx, &queries.RetryOptions{
+//		MaxAttempts: 5,
+//		TxOptions:   &drivers.TxOptions{Isolation: sql.LevelSerializable},
+//	}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*Account]) (bool, error) {
+//		...
+//	})
+//
+// If the options are nil, the defaults are used.
+//
+// The error of the last attempt is returned when the maximum number of attempts is reached,
+// errors which cannot be retried are returned immediately.
+//
+// If the context already has a transaction, the function is run once in a nested transaction.
+// A retryable error usually aborts the transaction as a whole, so only the outermost transaction is retried.
+func RunInTransactionWithRetry[T attrs.Definer](c context.Context, opts *RetryOptions, fn func(ctx context.Context, NewQuerySet ObjectsFunc[T]) (commit bool, err error), database ...string) error {
+	if opts == nil {
+		opts = &RetryOptions{}
+	}
+
+	var (
+		maxAttempts = opts.MaxAttempts
+		backoff     = opts.Backoff
+		isRetryable = opts.IsRetryable
+	)
+
+	if maxAttempts <= 0 {
+		maxAttempts = DEFAULT_TRANSACTION_ATTEMPTS
+	}
+
+	if backoff <= 0 {
+		backoff = DEFAULT_TRANSACTION_BACKOFF
+	}
+
+	if isRetryable == nil {
+		isRetryable = drivers.IsRetryable
+	}
+
+	var databaseName = getDatabaseName(nil, database...)
+	if _, ok := transactionForDatabase(c, databaseName); ok {
+		return RunInTransactionWithOptions(c, opts.TxOptions, fn, database...)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var ctx = context.WithValue(c, transactionAttemptContextKey{}, attempt)
+		err = RunInTransactionWithOptions(ctx, opts.TxOptions, fn, database...)
+		if err == nil || attempt >= maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		var delay = backoff << (attempt - 1)
+		if opts.MaxBackoff > 0 && (delay > opts.MaxBackoff || delay <= 0) {
+			delay = opts.MaxBackoff
+		}
+		delay += rand.N(delay/2 + 1)
+
+		logger.Warnf(
+			"RunInTransactionWithRetry: attempt %d of %d failed, retrying in %s: %v",
+			attempt, maxAttempts, delay, err,
+		)
+
+		var timer = time.NewTimer(delay)
+		select {
+		case <-c.Done():
+			timer.Stop()
+			return c.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package queries
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/Nigel2392/go-django-queries/src/drivers"
 	"github.com/Nigel2392/go-django-queries/src/query_errors"
@@ -26,6 +29,163 @@ func (n *nullTransaction) Commit() error {
 	return nil
 }
 
+// hookedTransaction is implemented by transactions which support [OnCommit] and [OnRollback] hooks.
+type hookedTransaction interface {
+	transactionHooks() *transactionHooks
+}
+
+// transactionHooks holds the functions which are run after a transaction is committed or rolled back.
+type transactionHooks struct {
+	mu         sync.Mutex
+	onCommit   []func()
+	onRollback []func()
+}
+
+func (h *transactionHooks) add(onCommit, onRollback func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if onCommit != nil {
+		h.onCommit = append(h.onCommit, onCommit)
+	}
+	if onRollback != nil {
+		h.onRollback = append(h.onRollback, onRollback)
+	}
+}
+
+// take removes and returns all hooks.
+func (h *transactionHooks) take() (onCommit, onRollback []func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	onCommit, onRollback = h.onCommit, h.onRollback
+	h.onCommit, h.onRollback = nil, nil
+	return onCommit, onRollback
+}
+
+// merge moves all hooks to the hooks of the parent transaction.
+func (h *transactionHooks) merge(parent *transactionHooks) {
+	var onCommit, onRollback = h.take()
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+	parent.onCommit = append(parent.onCommit, onCommit...)
+	parent.onRollback = append(parent.onRollback, onRollback...)
+}
+
+// committed runs the commit hooks, the rollback hooks are discarded.
+func (h *transactionHooks) committed() {
+	var onCommit, _ = h.take()
+	runTransactionHooks("commit", onCommit)
+}
+
+// rolledBack runs the rollback hooks, the commit hooks are discarded.
+func (h *transactionHooks) rolledBack() {
+	var _, onRollback = h.take()
+	runTransactionHooks("rollback", onRollback)
+}
+
+// runTransactionHooks runs the hooks in the order they were added,
+// a panic in a hook is recovered so that the other hooks still run.
+func runTransactionHooks(kind string, hooks []func()) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					logger.Errorf("panic in %s hook of transaction: %v", kind, rec)
+				}
+			}()
+			hook()
+		}()
+	}
+}
+
+// savepointNamer is implemented by transactions which generate
+// the names of the savepoints nested in them.
+type savepointNamer interface {
+	nextSavepoint() string
+}
+
+// savepoints is used to name savepoints in transactions which do not implement [savepointNamer].
+var savepoints atomic.Uint64
+
+// savepointTransaction is a transaction which is nested in another transaction.
+//
+// It is started with `SAVEPOINT sp_N`, where N is a counter of the outermost transaction,
+// each savepoint in a transaction has a unique name, also when savepoints are started next to each other.
+// Committing it releases the savepoint, rolling it back only undoes
+// the changes which were made after the savepoint was created.
+//
+// The outer transaction stays in control of the final commit, the [OnCommit] and [OnRollback]
+// hooks of a released savepoint are moved to the transaction it is nested in.
+type savepointTransaction struct {
+	drivers.Transaction
+	ctx   context.Context
+	name  string
+	done  bool
+	hooks transactionHooks
+}
+
+func (s *savepointTransaction) transactionHooks() *transactionHooks {
+	return &s.hooks
+}
+
+func (s *savepointTransaction) nextSavepoint() string {
+	return nextSavepoint(s.Transaction)
+}
+
+// nextSavepoint returns a unique name for a savepoint nested in the transaction.
+func nextSavepoint(tx drivers.Transaction) string {
+	if namer, ok := tx.(savepointNamer); ok {
+		return namer.nextSavepoint()
+	}
+	return fmt.Sprintf("sp_%d", savepoints.Add(1))
+}
+
+func newSavepointTransaction(ctx context.Context, tx drivers.Transaction) (*savepointTransaction, error) {
+	var sp = &savepointTransaction{
+		Transaction: tx,
+		ctx:         ctx,
+		name:        nextSavepoint(tx),
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint %s: %w", sp.name, err)
+	}
+
+	return sp, nil
+}
+
+func (s *savepointTransaction) Rollback() error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+
+	if _, err := s.Transaction.ExecContext(s.ctx, "ROLLBACK TO SAVEPOINT "+s.name); err != nil {
+		return fmt.Errorf("failed to rollback to savepoint %s: %w", s.name, err)
+	}
+
+	s.hooks.rolledBack()
+	return nil
+}
+
+func (s *savepointTransaction) Commit() error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+
+	if _, err := s.Transaction.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.name); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", s.name, err)
+	}
+
+	// the changes are only committed when the outer transaction is committed
+	if parent, ok := s.Transaction.(hookedTransaction); ok {
+		s.hooks.merge(parent.transactionHooks())
+	} else {
+		s.hooks.committed()
+	}
+	return nil
+}
+
 type dbSpecificTransaction struct {
 	drivers.Transaction
 	dbName string
@@ -38,6 +198,42 @@ func (c *dbSpecificTransaction) DatabaseName() string {
 type wrappedTransaction struct {
 	drivers.Transaction
 	compiler *genericQueryBuilder
+
+	// hooks is nil if the wrapped transaction holds the hooks itself,
+	// I.E. when a queryset is bound to a transaction from the context.
+	hooks *transactionHooks
+
+	// savepoints counts the savepoints nested in the transaction,
+	// it is nil if the wrapped transaction names the savepoints itself.
+	savepoints *atomic.Uint64
+}
+
+func newWrappedTransaction(tx drivers.Transaction, compiler *genericQueryBuilder) *wrappedTransaction {
+	var w = &wrappedTransaction{
+		Transaction: tx,
+		compiler:    compiler,
+	}
+	if _, ok := tx.(hookedTransaction); !ok {
+		w.hooks = &transactionHooks{}
+	}
+	if _, ok := tx.(savepointNamer); !ok {
+		w.savepoints = &atomic.Uint64{}
+	}
+	return w
+}
+
+func (w *wrappedTransaction) nextSavepoint() string {
+	if w.savepoints == nil {
+		return w.Transaction.(savepointNamer).nextSavepoint()
+	}
+	return fmt.Sprintf("sp_%d", w.savepoints.Add(1))
+}
+
+func (w *wrappedTransaction) transactionHooks() *transactionHooks {
+	if w.hooks == nil {
+		return w.Transaction.(hookedTransaction).transactionHooks()
+	}
+	return w.hooks
 }
 
 func (w *wrappedTransaction) Rollback() error {
@@ -49,12 +245,19 @@ func (w *wrappedTransaction) Rollback() error {
 	}
 	var err = w.Transaction.Rollback()
 	if errors.Is(err, sql.ErrTxDone) {
+		// the transaction was already rolled back, I.E. when its context was canceled
+		if w.hooks != nil {
+			w.hooks.rolledBack()
+		}
 		return nil
 	}
 	logger.Warnf("Rolling back transaction for %s (%v)", w.compiler.DatabaseName(), err)
 	if err != nil {
 		return fmt.Errorf("failed to rollback transaction for %s: %w", w.compiler.DatabaseName(), err)
 	}
+	if w.hooks != nil {
+		w.hooks.rolledBack()
+	}
 	return nil
 }
 
@@ -68,10 +271,21 @@ func (w *wrappedTransaction) Commit() error {
 	var err = w.Transaction.Commit()
 	// logger.Debugf("Committing transaction for %s (%v)", w.compiler.DatabaseName(), err)
 	if errors.Is(err, sql.ErrTxDone) {
+		// the transaction was already rolled back, I.E. when its context was canceled
+		if w.hooks != nil {
+			w.hooks.rolledBack()
+		}
 		return nil
 	}
 	if err != nil {
+		// a transaction which failed to commit is rolled back
+		if w.hooks != nil {
+			w.hooks.rolledBack()
+		}
 		return fmt.Errorf("failed to commit transaction for %s: %w", w.compiler.DatabaseName(), err)
 	}
+	if w.hooks != nil {
+		w.hooks.committed()
+	}
 	return nil
 }
@@ -115,6 +115,16 @@ type JoinDef struct {
 	Table            Table
 	TypeJoin         JoinType
 	JoinDefCondition *JoinDefCondition
+
+	// Subquery is joined as a derived table instead of the table,
+	// the alias of the table is used as the alias of the derived table.
+	//
+	// See [QuerySet.JoinSubquery] and [QuerySet.JoinLateral].
+	Subquery *GenericQuerySet
+
+	// Lateral indicates that the subquery is joined with `LATERAL`,
+	// which allows the subquery to reference the columns of the outer query.
+	Lateral bool
 }
 
 // FieldInfo represents information about a field in a query.
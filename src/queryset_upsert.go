@@ -0,0 +1,84 @@
+package queries
+
+import (
+	"fmt"
+
+	"github.com/Nigel2392/go-django-queries/src/query_errors"
+	"github.com/Nigel2392/go-django/src/core/attrs"
+)
+
+// OnConflict is the conflict clause of an insert query, see [QuerySet.BulkUpsert].
+//
+// It is passed to [QueryCompiler.BuildCreateQuery] through [QuerySetInternals.OnConflict].
+type OnConflict struct {
+	// Fields are the fields of the unique constraint to check for conflicts.
+	//
+	// MySQL and MariaDB check all unique constraints of the table, the fields are ignored.
+	Fields []attrs.FieldDefinition
+
+	// Update are the fields which are set to the values of the inserted row when a conflict occurs.
+	//
+	// If no fields are provided the existing row is left unchanged.
+	Update []attrs.FieldDefinition
+}
+
+// BulkUpsert is used to create multiple objects in the database,
+// objects which conflict with an existing row update that row instead.
+//
+// The conflict fields are the fields of a unique constraint, the update fields
+// are set to the values of the object when the object conflicts with an existing row.
+// If no update fields are provided, the existing row is left unchanged.
+//
+// It uses `ON CONFLICT (...) DO UPDATE` for PostgreSQL and SQLite, and
+// `ON DUPLICATE KEY UPDATE` for MySQL and MariaDB, the latter check all unique constraints of the table.
+//
+// The primary keys of the objects are set to the primary keys of the inserted or updated rows,
+// a single call cannot insert multiple objects which conflict with the same row.
+func (qs *QuerySet[T]) BulkUpsert(objects []T, conflictFields []string, updateFields []string) ([]T, error) {
+	if len(conflictFields) == 0 {
+		return nil, fmt.Errorf(
+			"QuerySet.BulkUpsert: no conflict fields provided for %T",
+			qs.internals.Model.Object,
+		)
+	}
+
+	var (
+		defs     = qs.internals.Model.Object.FieldDefs()
+		conflict = &OnConflict{
+			Fields: make([]attrs.FieldDefinition, 0, len(conflictFields)),
+			Update: make([]attrs.FieldDefinition, 0, len(updateFields)),
+		}
+	)
+
+	for _, name := range conflictFields {
+		var field, ok = defs.Field(name)
+		if !ok || field.ColumnName() == "" {
+			return nil, fmt.Errorf(
+				"QuerySet.BulkUpsert: conflict field %q not found in %T: %w",
+				name, qs.internals.Model.Object, query_errors.ErrFieldNotFound,
+			)
+		}
+		conflict.Fields = append(conflict.Fields, field)
+	}
+
+	for _, name := range updateFields {
+		var field, ok = defs.Field(name)
+		if !ok || field.ColumnName() == "" {
+			return nil, fmt.Errorf(
+				"QuerySet.BulkUpsert: update field %q not found in %T: %w",
+				name, qs.internals.Model.Object, query_errors.ErrFieldNotFound,
+			)
+		}
+
+		if field.IsPrimary() || !ForDBEdit(field) {
+			return nil, fmt.Errorf(
+				"QuerySet.BulkUpsert: field %q of %T cannot be updated",
+				name, qs.internals.Model.Object,
+			)
+		}
+
+		conflict.Update = append(conflict.Update, field)
+	}
+
+	return qs.bulkCreate(objects, conflict)
+}
@@ -1,6 +1,7 @@
 package queries_test
 
 import (
+	"fmt"
 	"slices"
 	"strings"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/Nigel2392/go-django-queries/src/expr"
 	"github.com/Nigel2392/go-django-queries/src/fields"
 	"github.com/Nigel2392/go-django-queries/src/models"
+	"github.com/Nigel2392/go-django-queries/src/quest"
 	django "github.com/Nigel2392/go-django/src"
 	"github.com/Nigel2392/go-django/src/core/attrs"
 )
@@ -1032,3 +1034,266 @@ func TestSubquery(t *testing.T) {
 
 	t.Logf("Row: %#v", rows[0].Object.(*TestStruct))
 }
+
+func TestSubqueryOuterRef(t *testing.T) {
+	var authors = []*Author{
+		{Name: "TestSubqueryOuterRef1"},
+		{Name: "TestSubqueryOuterRef2"},
+		{Name: "TestSubqueryOuterRef3"},
+	}
+
+	for _, author := range authors {
+		if err := queries.CreateObject(author); err != nil {
+			t.Fatalf("Failed to create author: %v", err)
+		}
+	}
+
+	var books = []*Book{
+		{Title: "TestSubqueryOuterRef1a", Author: authors[0]},
+		{Title: "TestSubqueryOuterRef1b", Author: authors[0]},
+		{Title: "TestSubqueryOuterRef2a", Author: authors[1]},
+	}
+
+	for _, book := range books {
+		if err := queries.CreateObject(book); err != nil {
+			t.Fatalf("Failed to create book: %v", err)
+		}
+	}
+
+	var authorIDs = []any{authors[0].ID, authors[1].ID, authors[2].ID}
+	defer func() {
+		if _, err := queries.Objects[attrs.Definer](&Book{}).Filter("Author__in", authorIDs...).Delete(); err != nil {
+			t.Errorf("Failed to delete books: %v", err)
+		}
+		if _, err := queries.Objects[attrs.Definer](&Author{}).Filter("ID__in", authorIDs...).Delete(); err != nil {
+			t.Errorf("Failed to delete authors: %v", err)
+		}
+	}()
+
+	t.Run("Annotate", func(t *testing.T) {
+		var qs = queries.Objects[attrs.Definer](&Author{}).
+			Select("ID", "Name").
+			Annotate("LastBook", queries.Subquery(
+				queries.Objects[attrs.Definer](&Book{}).
+					Select("Title").
+					Filter("Author", expr.OuterRef("ID")).
+					OrderBy("-ID").
+					Limit(1),
+			)).
+			Filter("ID__in", authorIDs...).
+			OrderBy("ID")
+
+		var rows, err = qs.All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		t.Logf("SQL: %s %v", qs.LatestQuery().SQL(), qs.LatestQuery().Args())
+
+		if len(rows) != len(authors) {
+			t.Fatalf("expected %d rows, got %d", len(authors), len(rows))
+		}
+
+		var expected = []any{books[1].Title, books[2].Title, nil}
+		for i, row := range rows {
+			if row.Annotations["LastBook"] != expected[i] {
+				t.Errorf("expected LastBook for %q to be %v, got %v", row.Object.(*Author).Name, expected[i], row.Annotations["LastBook"])
+			}
+		}
+	})
+
+	t.Run("FilterExists", func(t *testing.T) {
+		var rows, err = queries.Objects[attrs.Definer](&Author{}).
+			Filter("ID__in", authorIDs...).
+			Filter(queries.SubqueryExists(
+				queries.Objects[attrs.Definer](&Book{}).
+					Filter("Author", expr.OuterRef("ID")).
+					Filter("Title__endswith", "a"),
+			)).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(rows))
+		}
+
+		for i, row := range rows {
+			if row.Object.(*Author).ID != authors[i].ID {
+				t.Errorf("expected author %d to be %d, got %d", i, authors[i].ID, row.Object.(*Author).ID)
+			}
+		}
+	})
+
+	t.Run("OutsideSubquery", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected a panic when using OuterRef outside of a subquery")
+			}
+		}()
+
+		queries.Objects[attrs.Definer](&Book{}).
+			Filter("Author", expr.OuterRef("ID")).
+			All()
+	})
+
+	// the table of the subquery is aliased, the outer table would be shadowed otherwise
+	t.Run("SameModel", func(t *testing.T) {
+		var qs = queries.Objects[attrs.Definer](&Book{}).
+			Select("ID", "Title").
+			Filter("Author__in", authorIDs...).
+			Annotate("PreviousBook", queries.Subquery(
+				queries.Objects[attrs.Definer](&Book{}).
+					Select("Title").
+					Filter("Author", expr.OuterRef("Author")).
+					Filter("ID__lt", expr.OuterRef("ID")).
+					OrderBy("-ID").
+					Limit(1),
+			)).
+			OrderBy("ID")
+
+		var rows, err = qs.All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		t.Logf("SQL: %s %v", qs.LatestQuery().SQL(), qs.LatestQuery().Args())
+
+		if len(rows) != len(books) {
+			t.Fatalf("expected %d rows, got %d", len(books), len(rows))
+		}
+
+		var expected = []any{nil, books[0].Title, nil}
+		for i, row := range rows {
+			if row.Annotations["PreviousBook"] != expected[i] {
+				t.Errorf("expected PreviousBook for %q to be %v, got %v", row.Object.(*Book).Title, expected[i], row.Annotations["PreviousBook"])
+			}
+		}
+	})
+}
+
+type TestJSON struct {
+	ID    int64
+	Title string
+	Meta  map[string]any
+}
+
+func (t *TestJSON) FieldDefs() attrs.Definitions {
+	return attrs.Define[*TestJSON, any](t,
+		attrs.NewField(t, "ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.NewField(t, "Title", nil),
+		fields.NewJSONField[map[string]any](t, "Meta", &attrs.FieldConfig{
+			Null: true,
+		}),
+	)
+}
+
+var jsonObjects = []*TestJSON{
+	{Title: "Go", Meta: map[string]any{
+		"author": map[string]any{"name": "Rob", "age": 30},
+		"tags":   []any{"go", "sql"},
+		"draft":  false,
+	}},
+	{Title: "Rust", Meta: map[string]any{
+		"author": map[string]any{"name": "Graydon", "age": 40},
+		"tags":   []any{"rust"},
+	}},
+	{Title: "Python", Meta: map[string]any{
+		"title": "no author",
+	}},
+	{Title: "Empty"},
+}
+
+func TestJSONField(t *testing.T) {
+	var tables = quest.Table(t, &TestJSON{})
+	tables.Create()
+	defer tables.Drop()
+
+	for _, obj := range jsonObjects {
+		var created, err = queries.GetQuerySet(&TestJSON{}).Create(obj)
+		if err != nil {
+			t.Fatalf("Failed to create object: %v", err)
+		}
+		obj.ID = created.ID
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		var row, err = queries.GetQuerySet(&TestJSON{}).Filter("ID", jsonObjects[0].ID).Get()
+		if err != nil {
+			t.Fatalf("Failed to get object: %v", err)
+		}
+
+		var author, ok = row.Object.Meta["author"].(map[string]any)
+		if !ok {
+			t.Fatalf("expected author to be a map, got %T", row.Object.Meta["author"])
+		}
+
+		if author["name"] != "Rob" || author["age"] != float64(30) {
+			t.Errorf("expected author {Rob 30}, got %v", author)
+		}
+
+		if fmt.Sprint(row.Object.Meta["tags"]) != "[go sql]" {
+			t.Errorf("expected tags [go sql], got %v", row.Object.Meta["tags"])
+		}
+
+		row, err = queries.GetQuerySet(&TestJSON{}).Filter("ID", jsonObjects[3].ID).Get()
+		if err != nil {
+			t.Fatalf("Failed to get object: %v", err)
+		}
+
+		if row.Object.Meta != nil {
+			t.Errorf("expected a nil map for a NULL value, got %v", row.Object.Meta)
+		}
+	})
+
+	var lookups = []struct {
+		lookup   string
+		value    any
+		expected []string
+	}{
+		{"Meta__author__name", "Rob", []string{"Go"}},
+		{"Meta__author__name__icontains", "GRAY", []string{"Rust"}},
+		{"Meta__author__age__gt", 35, []string{"Rust"}},
+		{"Meta__author__age__lte", 40, []string{"Go", "Rust"}},
+		{"Meta__tags__0", "rust", []string{"Rust"}},
+		{"Meta__title__isnull", false, []string{"Python"}},
+		{"Meta__has_key", "author", []string{"Go", "Rust"}},
+		{"Meta__author__has_key", "age", []string{"Go", "Rust"}},
+		{"Meta__has_keys", []any{"author", "draft"}, []string{"Go"}},
+		{"Meta__contains", map[string]any{"author": map[string]any{"name": "Graydon"}}, []string{"Rust"}},
+		{"Meta__contains", map[string]any{"draft": false}, []string{"Go"}},
+		{"Meta__tags__contains", []any{"sql"}, []string{"Go"}},
+		{"Meta__tags__contains", []any{"go", "rust"}, []string{}},
+		{"Meta__contained_by", map[string]any{"title": "no author", "extra": 1}, []string{"Python"}},
+		{"Meta__tags__contained_by", []any{"go", "rust", "sql"}, []string{"Go", "Rust"}},
+		{"Meta__tags__contained_by", []any{"sql", "go"}, []string{"Go"}},
+		{"Meta__contained_by", map[string]any{"author": map[string]any{"name": "Rob", "age": 30}, "tags": []any{"go", "sql"}}, []string{}},
+		{"Meta__contained_by", map[string]any{"author": map[string]any{"name": "Rob", "age": 30}, "tags": []any{"go", "sql"}, "draft": false}, []string{"Go"}},
+	}
+
+	for _, test := range lookups {
+		t.Run(fmt.Sprintf("%s=%v", test.lookup, test.value), func(t *testing.T) {
+			var qs = queries.GetQuerySet(&TestJSON{}).
+				Filter(test.lookup, test.value).
+				OrderBy("ID")
+
+			var rows, err = qs.All()
+			if err != nil {
+				t.Fatalf("Failed to execute query: %v", err)
+			}
+
+			var titles = make([]string, 0, len(rows))
+			for _, row := range rows {
+				titles = append(titles, row.Object.Title)
+			}
+
+			if fmt.Sprint(titles) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v, got %v (%s)", test.expected, titles, qs.LatestQuery().SQL())
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package queries_test
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	queries "github.com/Nigel2392/go-django-queries/src"
 	"github.com/Nigel2392/go-django-queries/src/expr"
+	"github.com/Nigel2392/go-django-queries/src/quest"
 	"github.com/Nigel2392/go-django/src/core/attrs"
 )
 
@@ -45,3 +49,514 @@ func TestSelectFuncExpr(t *testing.T) {
 		t.Logf("Row: %#v", rows[0].Object.(*TestStruct))
 	})
 }
+
+func TestWindowFuncExpr(t *testing.T) {
+	var objects = []*TestStruct{
+		{Name: "TestWindowFuncExpr1", Text: "TestWindowFuncExpr1"},
+		{Name: "TestWindowFuncExpr1", Text: "TestWindowFuncExpr2"},
+		{Name: "TestWindowFuncExpr1", Text: "TestWindowFuncExpr3"},
+		{Name: "TestWindowFuncExpr2", Text: "TestWindowFuncExpr4"},
+	}
+
+	for _, obj := range objects {
+		if err := queries.CreateObject(obj); err != nil {
+			t.Fatalf("Failed to create object: %v", err)
+		}
+	}
+
+	var ids = make([]any, len(objects))
+	for i, obj := range objects {
+		ids[i] = obj.ID
+	}
+
+	defer func() {
+		if _, err := queries.GetQuerySet(&TestStruct{}).Filter("ID__in", ids...).Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
+		}
+	}()
+
+	t.Run("RowNumberPartitioned", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&TestStruct{}).
+			Select("ID", "Name", "Text").
+			Annotate("RowNumber", expr.Window(
+				expr.ROW_NUMBER(),
+				expr.PartitionBy("Name"),
+				expr.OrderBy("-ID"),
+			)).
+			Filter("ID__in", ids...).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != len(objects) {
+			t.Fatalf("expected %d rows, got %d", len(objects), len(rows))
+		}
+
+		var expected = []string{"3", "2", "1", "1"}
+		for i, row := range rows {
+			if got := fmt.Sprint(row.Annotations["RowNumber"]); got != expected[i] {
+				t.Errorf("expected RowNumber for %q to be %s, got %s", row.Object.Text, expected[i], got)
+			}
+		}
+	})
+
+	t.Run("RunningCount", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&TestStruct{}).
+			Select("ID", "Name", "Text").
+			Annotate("RunningCount", expr.Window(
+				expr.COUNT("ID"),
+				expr.PartitionBy("Name"),
+				expr.OrderBy("ID"),
+				expr.Frame(expr.FrameRows, expr.UnboundedPreceding, expr.CurrentRow),
+			)).
+			Annotate("PreviousTitle", expr.Window(
+				expr.LAG("Text", 1, "none"),
+				expr.PartitionBy("Name"),
+				expr.OrderBy("ID"),
+			)).
+			Filter("ID__in", ids...).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != len(objects) {
+			t.Fatalf("expected %d rows, got %d", len(objects), len(rows))
+		}
+
+		var (
+			expectedCount    = []string{"1", "2", "3", "1"}
+			expectedPrevious = []string{"none", objects[0].Text, objects[1].Text, "none"}
+		)
+		for i, row := range rows {
+			if got := fmt.Sprint(row.Annotations["RunningCount"]); got != expectedCount[i] {
+				t.Errorf("expected RunningCount for %q to be %s, got %s", row.Object.Text, expectedCount[i], got)
+			}
+
+			if got := fmt.Sprint(row.Annotations["PreviousTitle"]); got != expectedPrevious[i] {
+				t.Errorf("expected PreviousTitle for %q to be %s, got %s", row.Object.Text, expectedPrevious[i], got)
+			}
+		}
+	})
+
+	t.Run("RankAndNtile", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&TestStruct{}).
+			Select("ID", "Name", "Text").
+			Annotate("Rank", expr.Window(
+				expr.DENSE_RANK(),
+				expr.OrderBy("Name"),
+			)).
+			Annotate("Bucket", expr.Window(
+				expr.NTILE(2),
+				expr.OrderBy("ID"),
+			)).
+			Filter("ID__in", ids...).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		var (
+			expectedRank   = []string{"1", "1", "1", "2"}
+			expectedBucket = []string{"1", "1", "2", "2"}
+		)
+		for i, row := range rows {
+			if got := fmt.Sprint(row.Annotations["Rank"]); got != expectedRank[i] {
+				t.Errorf("expected Rank for %q to be %s, got %s", row.Object.Text, expectedRank[i], got)
+			}
+
+			if got := fmt.Sprint(row.Annotations["Bucket"]); got != expectedBucket[i] {
+				t.Errorf("expected Bucket for %q to be %s, got %s", row.Object.Text, expectedBucket[i], got)
+			}
+		}
+	})
+}
+
+func TestFilteredAggregateExpr(t *testing.T) {
+	var objects = []*TestStruct{
+		{Name: "TestFilteredAggregateExpr1", Text: "Published"},
+		{Name: "TestFilteredAggregateExpr1", Text: "Published"},
+		{Name: "TestFilteredAggregateExpr1", Text: "Draft"},
+		{Name: "TestFilteredAggregateExpr2", Text: "Draft"},
+	}
+
+	for _, obj := range objects {
+		if err := queries.CreateObject(obj); err != nil {
+			t.Fatalf("Failed to create object: %v", err)
+		}
+	}
+
+	var ids = make([]any, len(objects))
+	for i, obj := range objects {
+		ids[i] = obj.ID
+	}
+
+	defer func() {
+		if _, err := queries.GetQuerySet(&TestStruct{}).Filter("ID__in", ids...).Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
+		}
+	}()
+
+	t.Run("Aggregate", func(t *testing.T) {
+		var qs = queries.GetQuerySet(&TestStruct{}).
+			Filter("ID__in", ids...)
+
+		var result, err = qs.Aggregate(map[string]expr.Expression{
+			"Total":     expr.COUNT("ID"),
+			"Published": expr.COUNT("ID").Filter(expr.Q("Text", "Published")),
+			"Drafts": expr.COUNT("ID").Filter(
+				expr.Q("Text", "Draft"),
+				expr.Q("Name", "TestFilteredAggregateExpr1"),
+			),
+			"MaxDraft": expr.MAX("ID").Filter(expr.Q("Text", "Draft")),
+		})
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		t.Logf("SQL: %s %v", qs.LatestQuery().SQL(), qs.LatestQuery().Args())
+
+		var expected = map[string]string{
+			"Total":     "4",
+			"Published": "2",
+			"Drafts":    "1",
+			"MaxDraft":  fmt.Sprint(objects[3].ID),
+		}
+		for key, value := range expected {
+			if got := fmt.Sprint(result[key]); got != value {
+				t.Errorf("expected %s to be %s, got %s", key, value, got)
+			}
+		}
+	})
+
+	t.Run("AnnotateGroupBy", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&TestStruct{}).
+			Select("Name").
+			Filter("ID__in", ids...).
+			GroupBy("Name").
+			Annotate("Published", expr.COUNT("ID").Filter(expr.Q("Text", "Published"))).
+			Annotate("Drafts", expr.COUNT("ID").Filter(expr.Q("Text", "Draft"))).
+			OrderBy("Name").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(rows))
+		}
+
+		var (
+			expectedPublished = []string{"2", "0"}
+			expectedDrafts    = []string{"1", "1"}
+		)
+		for i, row := range rows {
+			if got := fmt.Sprint(row.Annotations["Published"]); got != expectedPublished[i] {
+				t.Errorf("expected Published for %q to be %s, got %s", row.Object.Name, expectedPublished[i], got)
+			}
+
+			if got := fmt.Sprint(row.Annotations["Drafts"]); got != expectedDrafts[i] {
+				t.Errorf("expected Drafts for %q to be %s, got %s", row.Object.Name, expectedDrafts[i], got)
+			}
+		}
+	})
+}
+
+type TestDateTime struct {
+	ID      int64
+	Name    string
+	Created time.Time
+}
+
+func (t *TestDateTime) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.Unbound("Name"),
+		attrs.Unbound("Created"),
+	)
+}
+
+func TestDateTimeExpr(t *testing.T) {
+	var tables = quest.Table(t, &TestDateTime{})
+	tables.Create()
+	defer tables.Drop()
+
+	var _, err = queries.GetQuerySet(&TestDateTime{}).BulkCreate([]*TestDateTime{
+		{Name: "Sunday", Created: time.Date(2023, 12, 31, 23, 30, 0, 0, time.UTC)},
+		{Name: "NewYear", Created: time.Date(2024, 1, 1, 8, 15, 30, 0, time.UTC)},
+		{Name: "January", Created: time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{Name: "LeapDay", Created: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create objects: %v", err)
+	}
+
+	var lookups = []struct {
+		lookup   string
+		value    any
+		expected []string
+	}{
+		{"Created__year", 2024, []string{"NewYear", "January", "LeapDay"}},
+		{"Created__year__lt", 2024, []string{"Sunday"}},
+		{"Created__month", 1, []string{"NewYear", "January"}},
+		{"Created__month__in", []any{2, 12}, []string{"Sunday", "LeapDay"}},
+		{"Created__day", 15, []string{"January"}},
+		{"Created__week", 52, []string{"Sunday"}},
+		{"Created__week__lte", 3, []string{"NewYear", "January"}},
+		{"Created__week_day", 1, []string{"Sunday"}},
+		{"Created__week_day", 2, []string{"NewYear", "January"}},
+		{"Created__hour__gte", 12, []string{"Sunday", "LeapDay"}},
+		{"Created__minute", 45, []string{"January"}},
+		{"Created__second", 30, []string{"NewYear"}},
+		{"Created__date", "2024-01-15", []string{"January"}},
+		{"Created__trunc_month", "2024-01-01 00:00:00", []string{"NewYear", "January"}},
+	}
+
+	for _, test := range lookups {
+		t.Run(fmt.Sprintf("%s=%v", test.lookup, test.value), func(t *testing.T) {
+			var values = []any{test.value}
+			if list, ok := test.value.([]any); ok {
+				values = list
+			}
+
+			var qs = queries.GetQuerySet(&TestDateTime{}).
+				Filter(test.lookup, values...).
+				OrderBy("Created")
+
+			var rows, err = qs.All()
+			if err != nil {
+				t.Fatalf("Failed to execute query: %v", err)
+			}
+
+			var names = make([]string, 0, len(rows))
+			for _, row := range rows {
+				names = append(names, row.Object.Name)
+			}
+
+			if fmt.Sprint(names) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v, got %v (%s)", test.expected, names, qs.LatestQuery().SQL())
+			}
+		})
+	}
+
+	t.Run("AnnotateExtract", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&TestDateTime{}).
+			Select("Name").
+			Annotate("Week", expr.Extract("Created", expr.DatePartWeek)).
+			Annotate("WeekDay", expr.Extract("Created", expr.DatePartWeekDay)).
+			OrderBy("Created").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		var expected = [][2]string{{"52", "1"}, {"1", "2"}, {"3", "2"}, {"9", "5"}}
+		for i, row := range rows {
+			var week, weekDay = fmt.Sprint(row.Annotations["Week"]), fmt.Sprint(row.Annotations["WeekDay"])
+			if week != expected[i][0] || weekDay != expected[i][1] {
+				t.Errorf("expected %s to be in week %s on day %s, got %s and %s", row.Object.Name, expected[i][0], expected[i][1], week, weekDay)
+			}
+		}
+	})
+
+	t.Run("GroupByTrunc", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&TestDateTime{}).
+			Select(expr.As("Name", expr.MAX("Name"))).
+			Annotate("Year", expr.Extract("Created", expr.DatePartYear)).
+			Annotate("Count", expr.COUNT("ID")).
+			GroupBy(expr.Trunc("Created", expr.DatePartYear)).
+			OrderBy("Year").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(rows))
+		}
+
+		var expected = [][2]string{{"2023", "1"}, {"2024", "3"}}
+		for i, row := range rows {
+			var year, count = fmt.Sprint(row.Annotations["Year"]), fmt.Sprint(row.Annotations["Count"])
+			if year != expected[i][0] || count != expected[i][1] {
+				t.Errorf("expected year %s to have %s rows, got %s and %s", expected[i][0], expected[i][1], year, count)
+			}
+		}
+	})
+
+	t.Run("UnsupportedPart", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected a panic when truncating to the week day")
+			}
+		}()
+
+		expr.Trunc("Created", expr.DatePartWeekDay)
+	})
+}
+
+type TestTextLookup struct {
+	ID    int64
+	Title string
+	Body  string
+}
+
+func (t *TestTextLookup) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.Unbound("Title"),
+		attrs.Unbound("Body"),
+	)
+}
+
+// TestTextSearch is backed by an FTS5 virtual table on SQLite,
+// the rowid of the virtual table is used as the primary key.
+type TestTextSearch struct {
+	ID    int64
+	Title string
+	Body  string
+}
+
+func (t *TestTextSearch) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+			Column:  "rowid",
+		}),
+		attrs.Unbound("Title"),
+		attrs.Unbound("Body"),
+	).WithTableName("test_text_search")
+}
+
+var textLookupObjects = []*TestTextLookup{
+	{Title: "Go", Body: "Go is an open source programming language"},
+	{Title: "Rust", Body: "A language empowering everyone"},
+	{Title: "Python", Body: "Python is a programming language that lets you work quickly"},
+}
+
+func TestTextLookups(t *testing.T) {
+	var tables = quest.Table(t, &TestTextLookup{})
+	tables.Create()
+	defer tables.Drop()
+
+	var _, err = queries.GetQuerySet(&TestTextLookup{}).BulkCreate(textLookupObjects)
+	if err != nil {
+		t.Fatalf("Failed to create objects: %v", err)
+	}
+
+	var lookups = []struct {
+		lookup   string
+		value    any
+		expected []string
+	}{
+		{"Title__regex", "^P", []string{"Python"}},
+		{"Title__regex", "^p", []string{}},
+		{"Title__iregex", "^p", []string{"Python"}},
+		{"Title__regex", "^(Go|Rust)$", []string{"Go", "Rust"}},
+		{"Body__regex", "programming language", []string{"Go", "Python"}},
+		{"Body__iregex", "^a ", []string{"Rust"}},
+		{"Body__lower__regex", "^python", []string{"Python"}},
+	}
+
+	for _, test := range lookups {
+		t.Run(fmt.Sprintf("%s=%v", test.lookup, test.value), func(t *testing.T) {
+			var qs = queries.GetQuerySet(&TestTextLookup{}).
+				Filter(test.lookup, test.value).
+				OrderBy("ID")
+
+			var rows, err = qs.All()
+			if err != nil {
+				t.Fatalf("Failed to execute query: %v", err)
+			}
+
+			var titles = make([]string, 0, len(rows))
+			for _, row := range rows {
+				titles = append(titles, row.Object.Title)
+			}
+
+			if fmt.Sprint(titles) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v, got %v (%s)", test.expected, titles, qs.LatestQuery().SQL())
+			}
+		})
+	}
+
+	t.Run("InvalidRegex", func(t *testing.T) {
+		if db_tag != "sqlite" {
+			t.Skip("the invalid pattern is only checked with the REGEXP function registered on the SQLite connection")
+		}
+
+		var _, err = queries.GetQuerySet(&TestTextLookup{}).
+			Filter("Title__regex", "(unclosed").
+			All()
+		if err == nil {
+			t.Fatal("expected an error for an invalid regular expression")
+		}
+	})
+}
+
+func TestTextSearchLookup(t *testing.T) {
+	if db_tag != "sqlite" {
+		t.Skip("the search lookup requires a full- text index, only the SQLite FTS5 virtual table is set up by this test")
+	}
+
+	attrs.RegisterModel(&TestTextSearch{})
+
+	var db = queries.GetQuerySet(&TestTextSearch{}).DB()
+	var _, err = db.ExecContext(context.Background(), "CREATE VIRTUAL TABLE test_text_search USING fts5(Title, Body)")
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			t.Skip("SQLite was built without FTS5, use the sqlite_fts5 build tag to run this test")
+		}
+		t.Fatalf("Failed to create virtual table: %v", err)
+	}
+	defer db.ExecContext(context.Background(), "DROP TABLE test_text_search")
+
+	for _, obj := range textLookupObjects {
+		_, err = db.ExecContext(context.Background(), "INSERT INTO test_text_search (Title, Body) VALUES (?, ?)", obj.Title, obj.Body)
+		if err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+
+	var searches = []struct {
+		query    string
+		expected []string
+	}{
+		{"language", []string{"Go", "Rust", "Python"}},
+		{"programming language", []string{"Go", "Python"}},
+		{"open programming", []string{"Go"}},
+		{"empowering", []string{"Rust"}},
+		{"empowering programming", []string{}},
+		{`"quickly" AND`, []string{}},
+	}
+
+	for _, test := range searches {
+		t.Run(test.query, func(t *testing.T) {
+			var qs = queries.GetQuerySet(&TestTextSearch{}).
+				Filter("Body__search", test.query).
+				OrderBy("ID")
+
+			var rows, err = qs.All()
+			if err != nil {
+				t.Fatalf("Failed to execute query: %v", err)
+			}
+
+			var titles = make([]string, 0, len(rows))
+			for _, row := range rows {
+				titles = append(titles, row.Object.Title)
+			}
+
+			if fmt.Sprint(titles) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v, got %v (%s)", test.expected, titles, qs.LatestQuery().SQL())
+			}
+		})
+	}
+}
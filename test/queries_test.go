@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Nigel2392/go-django-queries/internal"
 	queries "github.com/Nigel2392/go-django-queries/src"
@@ -21,7 +22,11 @@ import (
 	"github.com/Nigel2392/go-django/src/core/attrs"
 	"github.com/Nigel2392/go-django/src/core/logger"
 	"github.com/Nigel2392/go-django/src/forms/widgets"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
 )
 
 const (
@@ -2526,6 +2531,68 @@ func TestQueryGroupBy(t *testing.T) {
 	}
 }
 
+// A relation on the model itself is grouped by its column.
+func TestQueryGroupByRelation(t *testing.T) {
+	var (
+		parent1 = &Category{Name: "GroupByParent1"}
+		parent2 = &Category{Name: "GroupByParent2"}
+		child1  = &Category{Name: "GroupByChild1", Parent: parent1}
+		child2  = &Category{Name: "GroupByChild2", Parent: parent1}
+		child3  = &Category{Name: "GroupByChild3", Parent: parent2}
+	)
+
+	var categories = []*Category{parent1, parent2, child1, child2, child3}
+	for _, c := range categories {
+		if err := queries.CreateObject(c); err != nil {
+			t.Fatalf("Failed to create category: %v", err)
+		}
+	}
+
+	defer func() {
+		for i := len(categories) - 1; i >= 0; i-- {
+			if _, err := queries.GetQuerySet(&Category{}).Filter("ID", categories[i].ID).Delete(); err != nil {
+				t.Errorf("Failed to delete category: %v", err)
+			}
+		}
+	}()
+
+	var qs = queries.GetQuerySet(&Category{}).
+		Select("Parent").
+		Annotate("Children", expr.COUNT("ID")).
+		Filter("Name__in", child1.Name, child2.Name, child3.Name).
+		GroupBy("Parent").
+		OrderBy("Parent")
+
+	var rows, err = qs.All()
+	if err != nil {
+		t.Fatalf("Failed to group categories: %v", err)
+	}
+
+	t.Logf("SQL: %s %v", qs.LatestQuery().SQL(), qs.LatestQuery().Args())
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(rows))
+	}
+
+	var expected = []struct {
+		parent int
+		count  int64
+	}{
+		{parent1.ID, 2},
+		{parent2.ID, 1},
+	}
+
+	for i, row := range rows {
+		if row.Object.Parent == nil || row.Object.Parent.ID != expected[i].parent {
+			t.Errorf("Expected group %d to have parent %d, got %+v", i, expected[i].parent, row.Object.Parent)
+		}
+
+		if row.Annotations["Children"] != expected[i].count {
+			t.Errorf("Expected group %d to have %d children, got %v", i, expected[i].count, row.Annotations["Children"])
+		}
+	}
+}
+
 func TestAggregateCount(t *testing.T) {
 	var agg, err = queries.GetQuerySet[*Todo](&Todo{}).
 		Aggregate(map[string]expr.Expression{
@@ -2978,155 +3045,922 @@ func TestTransactionRollbackAfterInsert(t *testing.T) {
 	}
 }
 
-type TestRowsAffected struct {
-	ID   int64
-	Name string
-}
-
-func (t *TestRowsAffected) FieldDefs() attrs.Definitions {
-	return attrs.Define(t,
-		attrs.Unbound("ID", &attrs.FieldConfig{
-			Primary: true,
-		}),
-		attrs.Unbound("Name"),
-	)
-}
-
-func TestWorkingRowsAffected(t *testing.T) {
-	if !(db_tag == "mysql" || db_tag == "mysql_local" || db_tag == "mariadb") {
-		t.Skipf("Skipping test for %s database", db_tag)
-		return
+func TestRunInTransactionNested(t *testing.T) {
+	if db_tag == "mysql_local" {
+		t.Skip("the local MySQL server does not support savepoints")
 	}
 
-	var tables = quest.Table(t, &TestRowsAffected{})
-	tables.Create()
-	defer tables.Drop()
+	var names = func(ctx context.Context) []string {
+		var rows, err = queries.GetQuerySetWithContext(ctx, &TestTransaction{}).
+			Filter("Name__startswith", "Nested").
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to get objects: %v", err)
+		}
 
-	var objects = []*TestRowsAffected{
-		{Name: "Test1"},
-		{Name: "Test2"},
-		{Name: "Test3"},
+		var list = make([]string, 0, len(rows))
+		for _, row := range rows {
+			list = append(list, row.Object.Name)
+		}
+		return list
 	}
 
-	var created, delete = quest.CreateObjects(t, objects...)
 	defer func() {
-		if err := delete(0); err != nil {
-			t.Fatalf("Failed to delete objects: %v", err)
+		if _, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name__startswith", "Nested").Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
 		}
 	}()
 
-	var rowsAffected, err = queries.GetQuerySet(&TestRowsAffected{}).
-		Select("Name").
-		Filter("Name__in", "Test1", "Test2", "Test3").
-		Update(&TestRowsAffected{}, expr.As("Name", expr.UPPER("Name")))
-	if err != nil {
-		t.Fatalf("Failed to update objects: %v", err)
+	var create = func(NewQuerySet queries.ObjectsFunc[*TestTransaction], name string) error {
+		var _, err = NewQuerySet(&TestTransaction{}).Create(&TestTransaction{Name: name})
+		return err
 	}
 
-	if rowsAffected != int64(len(created)) || rowsAffected != 3 {
-		t.Fatalf("Expected %d rows affected, got %d", len(objects), rowsAffected)
+	var ctx = context.Background()
+	var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+		if err := create(NewQuerySet, "NestedOuter"); err != nil {
+			return false, err
+		}
+
+		// committed savepoint, the object is kept
+		var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			return true, create(NewQuerySet, "NestedCommitted")
+		})
+		if err != nil {
+			return false, err
+		}
+
+		// rolled back savepoint, only the object created inside of it is removed
+		err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			if err := create(NewQuerySet, "NestedRolledBack"); err != nil {
+				return false, err
+			}
+
+			// savepoints can be nested more than one level deep
+			var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				return true, create(NewQuerySet, "NestedDeep")
+			})
+			if err != nil {
+				return false, err
+			}
+
+			if got := strings.Join(names(ctx), ","); got != "NestedOuter,NestedCommitted,NestedRolledBack,NestedDeep" {
+				return false, fmt.Errorf("expected all objects inside of the savepoint, got %s", got)
+			}
+
+			return false, nil
+		})
+		if err != nil {
+			return false, err
+		}
+
+		// a savepoint which returns an error is rolled back as well
+		err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			if err := create(NewQuerySet, "NestedError"); err != nil {
+				return false, err
+			}
+			return true, errors.New("nested error")
+		})
+		if err == nil {
+			return false, errors.New("expected the nested transaction to return an error")
+		}
+
+		if got := strings.Join(names(ctx), ","); got != "NestedOuter,NestedCommitted" {
+			return false, fmt.Errorf("expected NestedOuter,NestedCommitted after rolling back, got %s", got)
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to run transaction: %v", err)
 	}
 
-	for _, obj := range created {
-		t.Logf("Updated object: %+v", obj)
+	if got := strings.Join(names(ctx), ","); got != "NestedOuter,NestedCommitted" {
+		t.Fatalf("Expected NestedOuter,NestedCommitted to be committed, got %s", got)
 	}
+
+	t.Run("RolledBackOuter", func(t *testing.T) {
+		var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				return true, create(NewQuerySet, "NestedInRolledBack")
+			})
+			return false, err
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		// the outer transaction decides, the released savepoint is rolled back with it
+		if got := strings.Join(names(ctx), ","); got != "NestedOuter,NestedCommitted" {
+			t.Fatalf("Expected the nested object to be rolled back with the outer transaction, got %s", got)
+		}
+	})
+
+	t.Run("SiblingSavepoints", func(t *testing.T) {
+		var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			var ctx1, sp1, err = queries.StartTransaction(ctx)
+			if err != nil {
+				return false, err
+			}
+
+			if _, err = queries.GetQuerySetWithContext(ctx1, &TestTransaction{}).Create(&TestTransaction{Name: "NestedSibling1"}); err != nil {
+				return false, err
+			}
+
+			// started next to the first savepoint, both are at the same depth
+			ctx2, _, err := queries.StartTransaction(ctx)
+			if err != nil {
+				return false, err
+			}
+
+			if _, err = queries.GetQuerySetWithContext(ctx2, &TestTransaction{}).Create(&TestTransaction{Name: "NestedSibling2"}); err != nil {
+				return false, err
+			}
+
+			// rolling back to the first savepoint undoes the changes of both
+			if err := sp1.Rollback(); err != nil {
+				return false, err
+			}
+
+			if got := strings.Join(names(ctx), ","); got != "NestedOuter,NestedCommitted" {
+				return false, fmt.Errorf("expected both siblings to be rolled back, got %s", got)
+			}
+
+			return false, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+	})
 }
 
-func TestQuerySetRows(t *testing.T) {
-	var tables = quest.Table(t, &TestRowsAffected{})
-	tables.Create()
-	defer tables.Drop()
+func TestGetOrCreateTransaction(t *testing.T) {
+	defer func() {
+		if _, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name__startswith", "getorcreatetx").Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
+		}
+	}()
 
-	var objects = []*TestRowsAffected{
-		{Name: "TestRows1"},
-		{Name: "TestRows2"},
-		{Name: "TestRows3"},
-	}
+	var err = queries.RunInTransaction(context.Background(), func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+		var innerCtx, tx, err = queries.GetOrCreateTransaction(ctx)
+		if err != nil {
+			return false, err
+		}
 
-	for _, obj := range objects {
-		if err := queries.CreateObject(obj); err != nil {
-			t.Fatalf("Failed to insert object: %v", err)
+		if innerCtx != ctx {
+			t.Errorf("Expected the context of the transaction to be returned")
+		}
+
+		if _, err = queries.GetQuerySetWithContext(innerCtx, &TestTransaction{}).Create(&TestTransaction{Name: "getorcreatetx1"}); err != nil {
+			return false, err
+		}
+
+		// the transaction from the context stays in control
+		if err = tx.Rollback(); err != nil {
+			return false, err
+		}
+
+		var count, _ = NewQuerySet(&TestTransaction{}).Filter("Name", "getorcreatetx1").Count()
+		if count != 1 {
+			t.Errorf("Expected the object to be kept after the rollback, got %d", count)
 		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to run transaction: %v", err)
 	}
 
-	var qs = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{})
-	var rows, err = qs.Rows("SELECT ![ID], ![Name] FROM #[SELF] WHERE LOWER(![Name]) LIKE LOWER(?[1])", "testrows%")
+	ctx, tx, err := queries.GetOrCreateTransaction(context.Background())
 	if err != nil {
-		t.Fatalf("Failed to get rows: %v (%s)", err, qs.LatestQuery().SQL())
+		t.Fatalf("Failed to start transaction: %v", err)
 	}
-	defer rows.Close()
 
-	var count int
-	for rows.Next() {
-		var row TestRowsAffected
-		if err := rows.Scan(&row.ID, &row.Name); err != nil {
-			t.Fatalf("Failed to scan row: %v", err)
-		}
-		count++
-		t.Logf("Row %d: %+v", count, row)
+	if _, err = queries.GetQuerySetWithContext(ctx, &TestTransaction{}).Create(&TestTransaction{Name: "getorcreatetx2"}); err != nil {
+		t.Fatalf("Failed to create object: %v", err)
 	}
 
-	if count != len(objects) {
-		t.Fatalf("Expected %d rows, got %d", len(objects), count)
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback transaction: %v", err)
 	}
 
-	_, err = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{}).Delete()
+	count, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name__startswith", "getorcreatetx").Count()
 	if err != nil {
-		t.Fatalf("Failed to delete objects: %v", err)
+		t.Fatalf("Failed to count objects: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected the new transaction to be rolled back, got %d objects", count)
 	}
 }
 
-func TestQuerySetRow(t *testing.T) {
-	var tables = quest.Table(t, &TestRowsAffected{})
-	tables.Create()
-	defer tables.Drop()
+func TestRunInTransactionWithOptions(t *testing.T) {
+	defer func() {
+		if _, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name", "TxOptions").Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
+		}
+	}()
 
-	var obj = &TestRowsAffected{
-		Name: "TestQuerySetRow",
+	var ctx = context.Background()
+	var err = queries.RunInTransactionWithOptions(ctx, &drivers.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+		var qs = NewQuerySet(&TestTransaction{})
+		if !qs.Compiler().InTransaction() {
+			return false, fmt.Errorf("expected query set to be in transaction")
+		}
+
+		var _, err = qs.Create(&TestTransaction{Name: "TxOptions"})
+		return err == nil, err
+	})
+	if err != nil {
+		t.Fatalf("Failed to run transaction: %v", err)
 	}
 
-	if err := queries.CreateObject(obj); err != nil {
-		t.Fatalf("Failed to insert object: %v", err)
+	count, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name", "TxOptions").Count()
+	if err != nil {
+		t.Fatalf("Failed to count objects: %v", err)
 	}
 
-	var rows = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{}).
-		Row("SELECT ![ID], ![Name] FROM #[SELF] WHERE ![Name] = ?", "TestQuerySetRow")
-	if err := rows.Err(); err != nil {
-		t.Fatalf("Failed to get rows: %v", err)
+	if count != 1 {
+		t.Fatalf("Expected 1 object to be committed, got %d", count)
 	}
 
-	var row TestRowsAffected
-	if err := rows.Scan(&row.ID, &row.Name); err != nil {
-		t.Fatalf("Failed to scan row: %v", err)
+	t.Run("PGX", func(t *testing.T) {
+		var opts, err = (&drivers.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}).PGX()
+		if err != nil {
+			t.Fatalf("Failed to convert options: %v", err)
+		}
+
+		if opts.IsoLevel != pgx.RepeatableRead || opts.AccessMode != pgx.ReadOnly {
+			t.Fatalf("Expected repeatable read and read only, got %q and %q", opts.IsoLevel, opts.AccessMode)
+		}
+
+		_, err = (&drivers.TxOptions{Isolation: sql.LevelLinearizable}).PGX()
+		if !errors.Is(err, query_errors.ErrNotImplemented) {
+			t.Fatalf("Expected ErrNotImplemented, got %v", err)
+		}
+	})
+
+	t.Run("SQL", func(t *testing.T) {
+		var opts = (&drivers.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}).SQL()
+		if opts.Isolation != sql.LevelSerializable || !opts.ReadOnly {
+			t.Fatalf("Expected serializable and read only, got %+v", opts)
+		}
+
+		if (*drivers.TxOptions)(nil).SQL() != nil {
+			t.Fatalf("Expected nil options to return nil")
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		var err = queries.RunInTransactionWithOptions(ctx, &drivers.TxOptions{ReadOnly: true}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			var _, err = NewQuerySet(&TestTransaction{}).Count()
+			return err == nil, err
+		})
+
+		switch {
+		case db_tag == "sqlite" && !errors.Is(err, query_errors.ErrNotImplemented):
+			t.Fatalf("Expected ErrNotImplemented, got %v", err)
+		case db_tag != "sqlite" && err != nil:
+			t.Fatalf("Failed to run read-only transaction: %v", err)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			var _, _, err = queries.StartTransactionWithOptions(ctx, &drivers.TxOptions{Isolation: sql.LevelSerializable})
+			if !errors.Is(err, query_errors.ErrTransactionStarted) {
+				t.Errorf("Expected ErrTransactionStarted, got %v", err)
+			}
+
+			err = queries.RunInTransactionWithOptions(ctx, &drivers.TxOptions{ReadOnly: true}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				t.Error("Expected the nested transaction not to be started")
+				return true, nil
+			})
+			if !errors.Is(err, query_errors.ErrTransactionStarted) {
+				t.Errorf("Expected ErrTransactionStarted, got %v", err)
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+	})
+}
+
+func TestRunInTransactionWithRetry(t *testing.T) {
+	defer func() {
+		if _, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name__startswith", "Retry").Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
+		}
+	}()
+
+	var count = func(name string) int64 {
+		var count, err = queries.GetQuerySet(&TestTransaction{}).Filter("Name", name).Count()
+		if err != nil {
+			t.Fatalf("Failed to count objects: %v", err)
+		}
+		return count
 	}
-	t.Logf("Row %+v", row)
 
-	var _, err = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{}).Delete()
-	if err != nil {
-		t.Fatalf("Failed to delete objects: %v", err)
+	var busy = sqlite3.Error{Code: sqlite3.ErrBusy}
+	var opts = &queries.RetryOptions{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
 	}
-}
 
-//
-//	func TestFakeModel(t *testing.T) {
-//
-//		var m = models.FakeModel(&models.FakeModelObject{
-//			Data:      make(models.MapDataStore),
-//			TableName: "images",
-//			FieldFuncs: []models.NewFieldFunc{
-//				func(mds queries.ModelDataStore, d1 attrs.Definer, d2 attrs.Definitions) attrs.Field {
-//					var (
-//						iface    = 0
-//						ifacePtr = &iface
-//					)
-//					mds.SetValue("ID", ifacePtr)
-//					return fields.NewDataModelField[int](
-//						d1, ifacePtr, "ID",
-//					)
-//				},
-//				func(mds queries.ModelDataStore, d1 attrs.Definer, d2 attrs.Definitions) attrs.Field {
-//					var (
-//						iface    = ""
+	t.Run("RetriedUntilSuccess", func(t *testing.T) {
+		var attempts []int
+		var err = queries.RunInTransactionWithRetry(context.Background(), opts, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			attempts = append(attempts, queries.TransactionAttempt(ctx))
+
+			if _, err := NewQuerySet(&TestTransaction{}).Create(&TestTransaction{Name: "RetrySuccess"}); err != nil {
+				return false, err
+			}
+
+			if len(attempts) < 3 {
+				return false, busy
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if fmt.Sprint(attempts) != "[1 2 3]" {
+			t.Fatalf("Expected attempts [1 2 3], got %v", attempts)
+		}
+
+		// the objects created by the failed attempts are rolled back
+		if c := count("RetrySuccess"); c != 1 {
+			t.Fatalf("Expected 1 object, got %d", c)
+		}
+	})
+
+	t.Run("MaxAttempts", func(t *testing.T) {
+		var attempts int
+		var err = queries.RunInTransactionWithRetry(context.Background(), opts, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			attempts++
+			if _, err := NewQuerySet(&TestTransaction{}).Create(&TestTransaction{Name: "RetryMaxAttempts"}); err != nil {
+				return false, err
+			}
+			return false, busy
+		})
+		if !drivers.IsRetryable(err) {
+			t.Fatalf("Expected a retryable error, got %v", err)
+		}
+
+		if attempts != 3 {
+			t.Fatalf("Expected 3 attempts, got %d", attempts)
+		}
+
+		if c := count("RetryMaxAttempts"); c != 0 {
+			t.Fatalf("Expected 0 objects, got %d", c)
+		}
+	})
+
+	t.Run("NotRetryable", func(t *testing.T) {
+		var attempts int
+		var err = queries.RunInTransactionWithRetry(context.Background(), opts, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			attempts++
+			return false, errors.New("not retryable")
+		})
+		if err == nil {
+			t.Fatalf("Expected an error, got nil")
+		}
+
+		if attempts != 1 {
+			t.Fatalf("Expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		if db_tag == "mysql_local" {
+			t.Skip("the local MySQL server does not support savepoints")
+		}
+
+		var attempts int
+		var err = queries.RunInTransaction(context.Background(), func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			var err = queries.RunInTransactionWithRetry(ctx, opts, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				attempts++
+				return false, busy
+			})
+			return err == nil, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		// only the outermost transaction is retried
+		if attempts != 1 {
+			t.Fatalf("Expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("IsRetryable", func(t *testing.T) {
+		var retryable = []error{
+			busy,
+			fmt.Errorf("wrapped: %w", busy),
+			&pgconn.PgError{Code: "40001"},
+			&pgconn.PgError{Code: "40P01"},
+			&mysql.MySQLError{Number: 1213},
+			&mysql.MySQLError{Number: 1205},
+		}
+
+		for _, err := range retryable {
+			if !drivers.IsRetryable(err) {
+				t.Errorf("Expected %v to be retryable", err)
+			}
+		}
+
+		var notRetryable = []error{
+			nil,
+			errors.New("some error"),
+			sqlite3.Error{Code: sqlite3.ErrConstraint},
+			&pgconn.PgError{Code: "23505"},
+			&mysql.MySQLError{Number: 1062},
+		}
+
+		for _, err := range notRetryable {
+			if drivers.IsRetryable(err) {
+				t.Errorf("Expected %v not to be retryable", err)
+			}
+		}
+	})
+}
+
+func TestTransactionHooks(t *testing.T) {
+	var calls []string
+	var hook = func(name string) func() {
+		return func() { calls = append(calls, name) }
+	}
+
+	var run = func(commit bool, fn func(ctx context.Context) error) error {
+		return queries.RunInTransaction(context.Background(), func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+			return commit, fn(ctx)
+		})
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		calls = nil
+		var err = run(true, func(ctx context.Context) error {
+			queries.OnCommit(ctx, hook("commit1"))
+			queries.OnRollback(ctx, hook("rollback"))
+			queries.OnCommit(ctx, hook("commit2"))
+
+			if len(calls) != 0 {
+				return fmt.Errorf("expected no hooks to run before commit, got %v", calls)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if fmt.Sprint(calls) != "[commit1 commit2]" {
+			t.Fatalf("Expected [commit1 commit2], got %v", calls)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		calls = nil
+		var err = run(false, func(ctx context.Context) error {
+			queries.OnCommit(ctx, hook("commit"))
+			queries.OnRollback(ctx, hook("rollback"))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if fmt.Sprint(calls) != "[rollback]" {
+			t.Fatalf("Expected [rollback], got %v", calls)
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		if db_tag == "mysql_local" {
+			t.Skip("the local MySQL server does not support savepoints")
+		}
+
+		calls = nil
+		var err = run(true, func(ctx context.Context) error {
+			queries.OnCommit(ctx, hook("outer"))
+
+			var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				queries.OnCommit(ctx, hook("released"))
+				return true, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				queries.OnCommit(ctx, hook("discarded"))
+				queries.OnRollback(ctx, hook("savepoint rollback"))
+				return false, nil
+			})
+			if err != nil {
+				return err
+			}
+
+			// the hooks of the released savepoint wait for the outermost commit
+			if fmt.Sprint(calls) != "[savepoint rollback]" {
+				return fmt.Errorf("expected [savepoint rollback] before commit, got %v", calls)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if fmt.Sprint(calls) != "[savepoint rollback outer released]" {
+			t.Fatalf("Expected [savepoint rollback outer released], got %v", calls)
+		}
+	})
+
+	t.Run("NestedOuterRollback", func(t *testing.T) {
+		if db_tag == "mysql_local" {
+			t.Skip("the local MySQL server does not support savepoints")
+		}
+
+		calls = nil
+		var err = run(false, func(ctx context.Context) error {
+			return queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestTransaction]) (bool, error) {
+				queries.OnCommit(ctx, hook("commit"))
+				queries.OnRollback(ctx, hook("rollback"))
+				return true, nil
+			})
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if fmt.Sprint(calls) != "[rollback]" {
+			t.Fatalf("Expected [rollback], got %v", calls)
+		}
+	})
+
+	t.Run("NoTransaction", func(t *testing.T) {
+		calls = nil
+		queries.OnCommit(context.Background(), hook("commit"))
+		queries.OnRollback(context.Background(), hook("rollback"))
+
+		if fmt.Sprint(calls) != "[commit]" {
+			t.Fatalf("Expected [commit], got %v", calls)
+		}
+	})
+
+	t.Run("StartTransaction", func(t *testing.T) {
+		calls = nil
+		var ctx, tx, err = queries.StartTransaction(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to start transaction: %v", err)
+		}
+
+		queries.OnCommit(ctx, hook("commit"))
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Failed to commit transaction: %v", err)
+		}
+
+		if fmt.Sprint(calls) != "[commit]" {
+			t.Fatalf("Expected [commit], got %v", calls)
+		}
+	})
+
+	// the transaction is rolled back when its context is canceled,
+	// committing it afterwards fails with sql.ErrTxDone or the context error
+	t.Run("ContextCanceled", func(t *testing.T) {
+		if db_tag == "sqlite" {
+			t.Skip("canceling the context closes the connection to the in-memory SQLite database")
+		}
+
+		calls = nil
+		var ctx, cancel = context.WithCancel(context.Background())
+		var txCtx, tx, err = queries.StartTransaction(ctx)
+		if err != nil {
+			t.Fatalf("Failed to start transaction: %v", err)
+		}
+
+		queries.OnCommit(txCtx, hook("commit"))
+		queries.OnRollback(txCtx, hook("rollback"))
+		cancel()
+
+		// give database/sql the time to roll back the transaction
+		time.Sleep(50 * time.Millisecond)
+
+		if err := tx.Commit(); err != nil && !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected the commit to fail with context.Canceled, got %v", err)
+		}
+
+		if fmt.Sprint(calls) != "[rollback]" {
+			t.Fatalf("Expected [rollback], got %v", calls)
+		}
+	})
+}
+
+type TestMultiAudit struct {
+	ID   int64
+	Name string
+}
+
+func (t *TestMultiAudit) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.Unbound("Name"),
+	).WithTableName("test_multi_audit")
+}
+
+func (t *TestMultiAudit) QuerySetDatabase() string {
+	return "multi_audit"
+}
+
+// failingCommitDatabase starts transactions which fail to commit.
+type failingCommitDatabase struct {
+	drivers.Database
+}
+
+func (d *failingCommitDatabase) Begin(ctx context.Context, opts *drivers.TxOptions) (drivers.Transaction, error) {
+	var tx, err = d.Database.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &failingCommitTransaction{tx}, nil
+}
+
+type failingCommitTransaction struct {
+	drivers.Transaction
+}
+
+func (t *failingCommitTransaction) Commit() error {
+	if err := t.Transaction.Rollback(); err != nil {
+		return err
+	}
+	return errors.New("commit failed")
+}
+
+func TestRunInMultiTransaction(t *testing.T) {
+	var auditDB, err = drivers.Open(context.Background(), "sqlite3", "file:queries_multi_audit?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("Failed to open audit database: %v", err)
+	}
+	defer auditDB.Close()
+
+	attrs.RegisterModel(&TestMultiAudit{})
+
+	django.Global.Settings.Set("multi_audit", auditDB)
+	django.Global.Settings.Set("multi_failing", &failingCommitDatabase{auditDB})
+
+	if _, err = auditDB.ExecContext(context.Background(), "CREATE TABLE test_multi_audit (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create audit table: %v", err)
+	}
+
+	defer func() {
+		if _, err := queries.GetQuerySet(&TestTransaction{}).Filter("Name__startswith", "Multi").Delete(); err != nil {
+			t.Errorf("Failed to delete objects: %v", err)
+		}
+	}()
+
+	var counts = func(name string) (int64, int64) {
+		var count, err = queries.GetQuerySet(&TestTransaction{}).Filter("Name", name).Count()
+		if err != nil {
+			t.Fatalf("Failed to count objects: %v", err)
+		}
+
+		auditCount, err := queries.GetQuerySet(&TestMultiAudit{}).Filter("Name", name).Count()
+		if err != nil {
+			t.Fatalf("Failed to count audit objects: %v", err)
+		}
+
+		return count, auditCount
+	}
+
+	var create = func(NewQuerySet queries.ObjectsFunc[attrs.Definer], name string) error {
+		if _, err := NewQuerySet(&TestTransaction{}).Create(&TestTransaction{Name: name}); err != nil {
+			return err
+		}
+		_, err := NewQuerySet(&TestMultiAudit{}).Create(&TestMultiAudit{Name: name})
+		return err
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		var err = queries.RunInMultiTransaction(context.Background(), []string{django.APPVAR_DATABASE, "multi_audit"}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[attrs.Definer]) (bool, error) {
+			return true, create(NewQuerySet, "MultiCommit")
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if c, a := counts("MultiCommit"); c != 1 || a != 1 {
+			t.Fatalf("Expected 1 object in both databases, got %d and %d", c, a)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		var err = queries.RunInMultiTransaction(context.Background(), []string{django.APPVAR_DATABASE, "multi_audit"}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[attrs.Definer]) (bool, error) {
+			return false, create(NewQuerySet, "MultiRollback")
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if c, a := counts("MultiRollback"); c != 0 || a != 0 {
+			t.Fatalf("Expected no objects in both databases, got %d and %d", c, a)
+		}
+	})
+
+	t.Run("CrossDatabase", func(t *testing.T) {
+		var err = queries.RunInMultiTransaction(context.Background(), []string{django.APPVAR_DATABASE}, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[attrs.Definer]) (bool, error) {
+			return true, create(NewQuerySet, "MultiCrossDatabase")
+		})
+		if !errors.Is(err, query_errors.ErrCrossDatabaseTransaction) {
+			t.Fatalf("Expected ErrCrossDatabaseTransaction, got %v", err)
+		}
+
+		if c, a := counts("MultiCrossDatabase"); c != 0 || a != 0 {
+			t.Fatalf("Expected no objects in both databases, got %d and %d", c, a)
+		}
+	})
+
+	t.Run("CommitFailed", func(t *testing.T) {
+		var databases = []string{django.APPVAR_DATABASE, "multi_failing", "multi_audit"}
+		var err = queries.RunInMultiTransaction(context.Background(), databases, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[attrs.Definer]) (bool, error) {
+			return true, create(NewQuerySet, "MultiCommitFailed")
+		})
+
+		var multiErr *queries.MultiTransactionError
+		if !errors.As(err, &multiErr) {
+			t.Fatalf("Expected a MultiTransactionError, got %v", err)
+		}
+
+		if multiErr.Database != "multi_failing" {
+			t.Errorf("Expected multi_failing to fail, got %q", multiErr.Database)
+		}
+
+		if fmt.Sprint(multiErr.Committed) != fmt.Sprintf("[%s]", django.APPVAR_DATABASE) {
+			t.Errorf("Expected [%s] to be committed, got %v", django.APPVAR_DATABASE, multiErr.Committed)
+		}
+
+		if fmt.Sprint(multiErr.RolledBack) != "[multi_failing multi_audit]" {
+			t.Errorf("Expected [multi_failing multi_audit] to be rolled back, got %v", multiErr.RolledBack)
+		}
+
+		if c, a := counts("MultiCommitFailed"); c != 1 || a != 0 {
+			t.Fatalf("Expected 1 committed object and no audit objects, got %d and %d", c, a)
+		}
+	})
+}
+
+type TestRowsAffected struct {
+	ID   int64
+	Name string
+}
+
+func (t *TestRowsAffected) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.Unbound("Name"),
+	)
+}
+
+func TestWorkingRowsAffected(t *testing.T) {
+	if !(db_tag == "mysql" || db_tag == "mysql_local" || db_tag == "mariadb") {
+		t.Skipf("Skipping test for %s database", db_tag)
+		return
+	}
+
+	var tables = quest.Table(t, &TestRowsAffected{})
+	tables.Create()
+	defer tables.Drop()
+
+	var objects = []*TestRowsAffected{
+		{Name: "Test1"},
+		{Name: "Test2"},
+		{Name: "Test3"},
+	}
+
+	var created, delete = quest.CreateObjects(t, objects...)
+	defer func() {
+		if err := delete(0); err != nil {
+			t.Fatalf("Failed to delete objects: %v", err)
+		}
+	}()
+
+	var rowsAffected, err = queries.GetQuerySet(&TestRowsAffected{}).
+		Select("Name").
+		Filter("Name__in", "Test1", "Test2", "Test3").
+		Update(&TestRowsAffected{}, expr.As("Name", expr.UPPER("Name")))
+	if err != nil {
+		t.Fatalf("Failed to update objects: %v", err)
+	}
+
+	if rowsAffected != int64(len(created)) || rowsAffected != 3 {
+		t.Fatalf("Expected %d rows affected, got %d", len(objects), rowsAffected)
+	}
+
+	for _, obj := range created {
+		t.Logf("Updated object: %+v", obj)
+	}
+}
+
+func TestQuerySetRows(t *testing.T) {
+	var tables = quest.Table(t, &TestRowsAffected{})
+	tables.Create()
+	defer tables.Drop()
+
+	var objects = []*TestRowsAffected{
+		{Name: "TestRows1"},
+		{Name: "TestRows2"},
+		{Name: "TestRows3"},
+	}
+
+	for _, obj := range objects {
+		if err := queries.CreateObject(obj); err != nil {
+			t.Fatalf("Failed to insert object: %v", err)
+		}
+	}
+
+	var qs = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{})
+	var rows, err = qs.Rows("SELECT ![ID], ![Name] FROM #[SELF] WHERE LOWER(![Name]) LIKE LOWER(?[1])", "testrows%")
+	if err != nil {
+		t.Fatalf("Failed to get rows: %v (%s)", err, qs.LatestQuery().SQL())
+	}
+	defer rows.Close()
+
+	var count int
+	for rows.Next() {
+		var row TestRowsAffected
+		if err := rows.Scan(&row.ID, &row.Name); err != nil {
+			t.Fatalf("Failed to scan row: %v", err)
+		}
+		count++
+		t.Logf("Row %d: %+v", count, row)
+	}
+
+	if count != len(objects) {
+		t.Fatalf("Expected %d rows, got %d", len(objects), count)
+	}
+
+	_, err = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{}).Delete()
+	if err != nil {
+		t.Fatalf("Failed to delete objects: %v", err)
+	}
+}
+
+func TestQuerySetRow(t *testing.T) {
+	var tables = quest.Table(t, &TestRowsAffected{})
+	tables.Create()
+	defer tables.Drop()
+
+	var obj = &TestRowsAffected{
+		Name: "TestQuerySetRow",
+	}
+
+	if err := queries.CreateObject(obj); err != nil {
+		t.Fatalf("Failed to insert object: %v", err)
+	}
+
+	var rows = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{}).
+		Row("SELECT ![ID], ![Name] FROM #[SELF] WHERE ![Name] = ?", "TestQuerySetRow")
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Failed to get rows: %v", err)
+	}
+
+	var row TestRowsAffected
+	if err := rows.Scan(&row.ID, &row.Name); err != nil {
+		t.Fatalf("Failed to scan row: %v", err)
+	}
+	t.Logf("Row %+v", row)
+
+	var _, err = queries.GetQuerySet[attrs.Definer](&TestRowsAffected{}).Delete()
+	if err != nil {
+		t.Fatalf("Failed to delete objects: %v", err)
+	}
+}
+
+//
+//	func TestFakeModel(t *testing.T) {
+//
+//		var m = models.FakeModel(&models.FakeModelObject{
+//			Data:      make(models.MapDataStore),
+//			TableName: "images",
+//			FieldFuncs: []models.NewFieldFunc{
+//				func(mds queries.ModelDataStore, d1 attrs.Definer, d2 attrs.Definitions) attrs.Field {
+//					var (
+//						iface    = 0
+//						ifacePtr = &iface
+//					)
+//					mds.SetValue("ID", ifacePtr)
+//					return fields.NewDataModelField[int](
+//						d1, ifacePtr, "ID",
+//					)
+//				},
+//				func(mds queries.ModelDataStore, d1 attrs.Definer, d2 attrs.Definitions) attrs.Field {
+//					var (
+//						iface    = ""
 //						ifacePtr = &iface
 //					)
 //					mds.SetValue("Path", ifacePtr)
@@ -3158,3 +3992,1678 @@ func TestQuerySetRow(t *testing.T) {
 //		}
 //	}
 //
+
+func TestQuerySetCompound(t *testing.T) {
+	var tables = quest.Table(t, &TestRowsAffected{})
+	tables.Create()
+	defer tables.Drop()
+
+	var objects = []*TestRowsAffected{
+		{Name: "Compound1"},
+		{Name: "Compound2"},
+		{Name: "Compound3"},
+		{Name: "Compound4"},
+	}
+
+	for _, obj := range objects {
+		if err := queries.CreateObject(obj); err != nil {
+			t.Fatalf("Failed to insert object: %v", err)
+		}
+	}
+
+	var (
+		qsA = queries.GetQuerySet(&TestRowsAffected{}).Filter("Name__in", "Compound1", "Compound2", "Compound3")
+		qsB = queries.GetQuerySet(&TestRowsAffected{}).Filter("Name__in", "Compound3", "Compound4")
+	)
+
+	var names = func(rows queries.Rows[*TestRowsAffected]) []string {
+		var list = make([]string, 0, len(rows))
+		for _, row := range rows {
+			list = append(list, row.Object.Name)
+		}
+		return list
+	}
+
+	var skipUnsupported = func(t *testing.T, err error) {
+		if errors.Is(err, query_errors.ErrNotImplemented) {
+			t.Skipf("Skipping test for %s database: %v", db_tag, err)
+		}
+	}
+
+	t.Run("Union", func(t *testing.T) {
+		var rows, err = qsA.Union(qsB).OrderBy("Name").All()
+		if err != nil {
+			t.Fatalf("Failed to execute union: %v", err)
+		}
+
+		var got = strings.Join(names(rows), ",")
+		if got != "Compound1,Compound2,Compound3,Compound4" {
+			t.Fatalf("Expected all 4 objects, got %s", got)
+		}
+	})
+
+	t.Run("UnionOrderLimit", func(t *testing.T) {
+		if db_tag == "mysql_local" {
+			t.Skip("the local MySQL server ignores the offset of a compound query")
+		}
+
+		var rows, err = qsA.Union(qsB).OrderBy("-Name").Limit(2).Offset(1).All()
+		if err != nil {
+			t.Fatalf("Failed to execute union: %v", err)
+		}
+
+		var got = strings.Join(names(rows), ",")
+		if got != "Compound3,Compound2" {
+			t.Fatalf("Expected Compound3,Compound2, got %s", got)
+		}
+	})
+
+	t.Run("UnionAll", func(t *testing.T) {
+		var values, err = qsA.UnionAll(qsB).ValuesList("Name")
+		if err != nil {
+			t.Fatalf("Failed to execute union all: %v", err)
+		}
+
+		if len(values) != 5 {
+			t.Fatalf("Expected 5 rows, got %d: %v", len(values), values)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		var count, err = qsA.Union(qsB).Count()
+		if err != nil {
+			t.Fatalf("Failed to count union: %v", err)
+		}
+
+		if count != 4 {
+			t.Fatalf("Expected 4 rows, got %d", count)
+		}
+
+		count, err = qsA.UnionAll(qsB).Count()
+		if err != nil {
+			t.Fatalf("Failed to count union all: %v", err)
+		}
+
+		if count != 5 {
+			t.Fatalf("Expected 5 rows, got %d", count)
+		}
+	})
+
+	t.Run("Intersection", func(t *testing.T) {
+		var rows, err = qsA.Intersection(qsB).All()
+		skipUnsupported(t, err)
+		if err != nil {
+			t.Fatalf("Failed to execute intersection: %v", err)
+		}
+
+		var got = strings.Join(names(rows), ",")
+		if got != "Compound3" {
+			t.Fatalf("Expected Compound3, got %s", got)
+		}
+	})
+
+	t.Run("Difference", func(t *testing.T) {
+		var rows, err = qsA.Difference(qsB).OrderBy("Name").All()
+		skipUnsupported(t, err)
+		if err != nil {
+			t.Fatalf("Failed to execute difference: %v", err)
+		}
+
+		var got = strings.Join(names(rows), ",")
+		if got != "Compound1,Compound2" {
+			t.Fatalf("Expected Compound1,Compound2, got %s", got)
+		}
+	})
+
+	t.Run("ColumnMismatch", func(t *testing.T) {
+		var _, err = qsA.Select("ID", "Name").Union(qsB.Select("Name")).All()
+		if !errors.Is(err, query_errors.ErrTypeMismatch) {
+			t.Fatalf("Expected ErrTypeMismatch, got %v", err)
+		}
+	})
+}
+
+func TestQuerySetCTE(t *testing.T) {
+	var (
+		root       = &Category{Name: "CTERoot"}
+		child1     = &Category{Name: "CTEChild1", Parent: root}
+		child2     = &Category{Name: "CTEChild2", Parent: root}
+		grandchild = &Category{Name: "CTEGrandchild", Parent: child1}
+		other      = &Category{Name: "CTEOther"}
+	)
+
+	var categories = []*Category{root, child1, child2, grandchild, other}
+	for _, c := range categories {
+		if err := queries.CreateObject(c); err != nil {
+			t.Fatalf("Failed to create category: %v", err)
+		}
+	}
+
+	defer func() {
+		for i := len(categories) - 1; i >= 0; i-- {
+			if _, err := queries.GetQuerySet(&Category{}).Filter("ID", categories[i].ID).Delete(); err != nil {
+				t.Errorf("Failed to delete category: %v", err)
+			}
+		}
+	}()
+
+	var tree = func(rootID int) *queries.GenericQuerySet {
+		var base = queries.GetQuerySet[attrs.Definer](&Category{}).
+			Select("ID", "Name", "Parent").
+			Filter("ID", rootID)
+
+		var children = queries.GetQuerySet[attrs.Definer](&Category{}).
+			Select("ID", "Name", "Parent").
+			JoinCTE("tree", queries.TypeJoinInner, "Parent", "id")
+
+		return base.UnionAll(children)
+	}
+
+	var names = func(rows queries.Rows[*Category]) string {
+		var list = make([]string, 0, len(rows))
+		for _, row := range rows {
+			list = append(list, row.Object.Name)
+		}
+		return strings.Join(list, ",")
+	}
+
+	t.Run("RecursiveFilter", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&Category{}).
+			With("tree", tree(root.ID), true).
+			Filter("ID__in", queries.CTESubquery("tree", "id")).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if got := names(rows); got != "CTERoot,CTEChild1,CTEChild2,CTEGrandchild" {
+			t.Fatalf("Expected the tree of CTERoot, got %s", got)
+		}
+	})
+
+	t.Run("RecursiveSubtree", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&Category{}).
+			With("tree", tree(child1.ID), true).
+			Filter("ID__in", queries.CTESubquery("tree", "id")).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if got := names(rows); got != "CTEChild1,CTEGrandchild" {
+			t.Fatalf("Expected the tree of CTEChild1, got %s", got)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		var count, err = queries.GetQuerySet(&Category{}).
+			With("tree", tree(root.ID), true).
+			Filter("ID__in", queries.CTESubquery("tree", "id")).
+			Count()
+		if err != nil {
+			t.Fatalf("Failed to count: %v", err)
+		}
+
+		if count != 4 {
+			t.Fatalf("Expected 4 categories, got %d", count)
+		}
+	})
+
+	t.Run("JoinAndSelect", func(t *testing.T) {
+		var named = queries.GetQuerySet[attrs.Definer](&Category{}).
+			Select("ID", "Name").
+			Filter("Name__in", "CTEChild1", "CTEChild2")
+
+		var rows, err = queries.GetQuerySet(&Category{}).
+			With("named", named, false).
+			Select("ID", "Name").
+			JoinCTE("named", queries.TypeJoinInner, "ID", "id").
+			Annotate("CTEName", queries.CTEColumn("named", "name")).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if got := names(rows); got != "CTEChild1,CTEChild2" {
+			t.Fatalf("Expected CTEChild1,CTEChild2, got %s", got)
+		}
+
+		for _, row := range rows {
+			if row.Annotations["CTEName"] != row.Object.Name {
+				t.Errorf("Expected annotation CTEName to be %q, got %v", row.Object.Name, row.Annotations["CTEName"])
+			}
+		}
+	})
+}
+
+func TestQuerySetJoinSubquery(t *testing.T) {
+	var (
+		parent1 = &Category{Name: "JoinParent1"}
+		parent2 = &Category{Name: "JoinParent2"}
+		child1  = &Category{Name: "JoinChild1", Parent: parent1}
+		child2  = &Category{Name: "JoinChild2", Parent: parent1}
+		child3  = &Category{Name: "JoinChild3", Parent: parent2}
+	)
+
+	var categories = []*Category{parent1, parent2, child1, child2, child3}
+	for _, c := range categories {
+		if err := queries.CreateObject(c); err != nil {
+			t.Fatalf("Failed to create category: %v", err)
+		}
+	}
+
+	defer func() {
+		for i := len(categories) - 1; i >= 0; i-- {
+			if _, err := queries.GetQuerySet(&Category{}).Filter("ID", categories[i].ID).Delete(); err != nil {
+				t.Errorf("Failed to delete category: %v", err)
+			}
+		}
+	}()
+
+	var children = queries.GetQuerySet[attrs.Definer](&Category{}).
+		Select("Parent").
+		Annotate("Children", expr.COUNT("ID")).
+		Filter("Name__in", child1.Name, child2.Name, child3.Name).
+		GroupBy("Parent")
+
+	t.Run("SelectFilterOrderBy", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&Category{}).
+			JoinSubquery("counts", queries.TypeJoinInner, children, "ID", "Parent").
+			Select("ID", "Name", "counts.Children").
+			Filter("counts.Children__gte", 1).
+			OrderBy("-counts.Children", "ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != 2 {
+			t.Fatalf("Expected 2 rows, got %d", len(rows))
+		}
+
+		var expected = []struct {
+			name  string
+			count int64
+		}{
+			{"JoinParent1", 2},
+			{"JoinParent2", 1},
+		}
+
+		for i, row := range rows {
+			if row.Object.Name != expected[i].name {
+				t.Errorf("Expected row %d to be %q, got %q", i, expected[i].name, row.Object.Name)
+			}
+
+			var count, ok = row.Annotations["counts.Children"].(int64)
+			if !ok || count != expected[i].count {
+				t.Errorf("Expected %q to have %d children, got %v", row.Object.Name, expected[i].count, row.Annotations["counts.Children"])
+			}
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		var rows, err = queries.GetQuerySet(&Category{}).
+			JoinSubquery("counts", queries.TypeJoinInner, children, "ID", "Parent").
+			Filter("counts.Children__gt", 1).
+			All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if len(rows) != 1 || rows[0].Object.ID != parent1.ID {
+			t.Fatalf("Expected only JoinParent1, got %d rows", len(rows))
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		var count, err = queries.GetQuerySet(&Category{}).
+			JoinSubquery("counts", queries.TypeJoinInner, children, "ID", "Parent").
+			Count()
+		if err != nil {
+			t.Fatalf("Failed to count: %v", err)
+		}
+
+		if count != 2 {
+			t.Fatalf("Expected 2 categories, got %d", count)
+		}
+	})
+
+	t.Run("Lateral", func(t *testing.T) {
+		if db_tag != "postgres" && db_tag != "mysql" && db_tag != "mysql_local" {
+			t.Skipf("Skipping test for %s database: LATERAL joins are not supported", db_tag)
+		}
+
+		var authors = []*Author{
+			{Name: "JoinLateral1"},
+			{Name: "JoinLateral2"},
+			{Name: "JoinLateral3"},
+		}
+
+		for _, author := range authors {
+			if err := queries.CreateObject(author); err != nil {
+				t.Fatalf("Failed to create author: %v", err)
+			}
+		}
+
+		var books = []*Book{
+			{Title: "JoinLateral1A", Author: authors[0]},
+			{Title: "JoinLateral1B", Author: authors[0]},
+			{Title: "JoinLateral2A", Author: authors[1]},
+		}
+
+		for _, book := range books {
+			if err := queries.CreateObject(book); err != nil {
+				t.Fatalf("Failed to create book: %v", err)
+			}
+		}
+
+		var authorIDs = []any{authors[0].ID, authors[1].ID, authors[2].ID}
+		defer func() {
+			if _, err := queries.GetQuerySet[attrs.Definer](&Book{}).Filter("Author__in", authorIDs...).Delete(); err != nil {
+				t.Errorf("Failed to delete books: %v", err)
+			}
+			if _, err := queries.GetQuerySet[attrs.Definer](&Author{}).Filter("ID__in", authorIDs...).Delete(); err != nil {
+				t.Errorf("Failed to delete authors: %v", err)
+			}
+		}()
+
+		var latest = queries.GetQuerySet[attrs.Definer](&Book{}).
+			Select("ID", "Title").
+			Filter("Author", expr.OuterRef("ID")).
+			OrderBy("-ID").
+			Limit(1)
+
+		var qs = queries.GetQuerySet(&Author{}).
+			JoinLateral("latest", queries.TypeJoinLeft, latest).
+			Select("ID", "Name", "latest.Title").
+			Filter("ID__in", authorIDs...).
+			OrderBy("ID")
+
+		var rows, err = qs.All()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		t.Logf("SQL: %s %v", qs.LatestQuery().SQL(), qs.LatestQuery().Args())
+
+		if len(rows) != len(authors) {
+			t.Fatalf("Expected %d rows, got %d", len(authors), len(rows))
+		}
+
+		var expected = []any{books[1].Title, books[2].Title, nil}
+		for i, row := range rows {
+			if row.Object.ID != authors[i].ID {
+				t.Errorf("Expected row %d to be author %d, got %d", i, authors[i].ID, row.Object.ID)
+			}
+
+			if row.Annotations["latest.Title"] != expected[i] {
+				t.Errorf("Expected latest book of %q to be %v, got %v", row.Object.Name, expected[i], row.Annotations["latest.Title"])
+			}
+		}
+	})
+
+	t.Run("LateralNotSupported", func(t *testing.T) {
+		if db_tag != "sqlite" && db_tag != "mariadb" {
+			t.Skipf("Skipping test for %s database: LATERAL joins are supported", db_tag)
+		}
+
+		var latest = queries.GetQuerySet[attrs.Definer](&Book{}).
+			Select("ID", "Title").
+			Filter("Author", expr.OuterRef("ID")).
+			OrderBy("-ID").
+			Limit(1)
+
+		var _, err = queries.GetQuerySet(&Author{}).
+			JoinLateral("latest", queries.TypeJoinLeft, latest).
+			Select("ID", "latest.Title").
+			All()
+		if !errors.Is(err, query_errors.ErrNotImplemented) {
+			t.Fatalf("Expected ErrNotImplemented, got %v", err)
+		}
+	})
+
+	t.Run("CompoundColumnMismatch", func(t *testing.T) {
+		var compound = queries.GetQuerySet[attrs.Definer](&Category{}).
+			Select("ID", "Parent").
+			Union(queries.GetQuerySet[attrs.Definer](&Category{}).Select("Parent"))
+
+		var qs = queries.GetQuerySet(&Category{}).
+			JoinSubquery("parents", queries.TypeJoinInner, compound, "ID", "Parent")
+
+		var _, err = qs.All()
+		if !errors.Is(err, query_errors.ErrTypeMismatch) {
+			t.Fatalf("Expected ErrTypeMismatch, got %v", err)
+		}
+
+		_, err = qs.Count()
+		if !errors.Is(err, query_errors.ErrTypeMismatch) {
+			t.Fatalf("Expected ErrTypeMismatch when counting, got %v", err)
+		}
+	})
+}
+
+type TestPaginated struct {
+	ID    int64
+	Name  string
+	Score *int64
+}
+
+func (t *TestPaginated) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.Unbound("Name"),
+		attrs.Unbound("Score", &attrs.FieldConfig{
+			Null: true,
+		}),
+	)
+}
+
+func TestQuerySetPaginate(t *testing.T) {
+	var tables = quest.Table(t, &TestPaginated{})
+	tables.Create()
+	defer tables.Drop()
+
+	var score = func(i int64) *int64 { return &i }
+	var scores = []*int64{
+		score(5), nil, score(3), score(5), nil, score(1), score(3),
+	}
+
+	for i, s := range scores {
+		var obj = &TestPaginated{
+			Name:  fmt.Sprintf("Paginated%d", i),
+			Score: s,
+		}
+		if err := queries.CreateObject(obj); err != nil {
+			t.Fatalf("Failed to insert object: %v", err)
+		}
+	}
+
+	var ids = func(rows queries.Rows[*TestPaginated]) []string {
+		var list = make([]string, 0, len(rows))
+		for _, row := range rows {
+			list = append(list, fmt.Sprint(row.Object.ID))
+		}
+		return list
+	}
+
+	var tests = []struct {
+		name     string
+		orderBy  []string
+		expected []string
+	}{
+		{"PrimaryKey", nil, []string{"ID"}},
+		{"Ascending", []string{"Score"}, []string{"Score", "ID"}},
+		{"Descending", []string{"-Score"}, []string{"-Score", "-ID"}},
+		{"Multiple", []string{"-Score", "Name"}, []string{"-Score", "Name", "ID"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var expectedRows, err = queries.GetQuerySet(&TestPaginated{}).
+				OrderBy(test.expected...).
+				All()
+			if err != nil {
+				t.Fatalf("Failed to retrieve objects: %v", err)
+			}
+			var expected = strings.Join(ids(expectedRows), ",")
+
+			var qs = queries.GetQuerySet(&TestPaginated{})
+			if len(test.orderBy) > 0 {
+				qs = qs.OrderBy(test.orderBy...)
+			}
+
+			// walk forwards
+			var (
+				got    = make([]string, 0, len(scores))
+				pages  = make([]*queries.Page[*TestPaginated], 0)
+				cursor string
+			)
+			for {
+				page, err := qs.Paginate(cursor, 3)
+				if err != nil {
+					t.Fatalf("Failed to paginate: %v", err)
+				}
+
+				if len(pages) > 0 && !page.HasPrevious() {
+					t.Fatalf("Expected page %d to have a previous page", len(pages)+1)
+				}
+
+				got = append(got, ids(page.Rows)...)
+				pages = append(pages, page)
+				if !page.HasNext() {
+					break
+				}
+				cursor = page.Next
+			}
+
+			if strings.Join(got, ",") != expected {
+				t.Fatalf("Expected %s, got %s", expected, strings.Join(got, ","))
+			}
+
+			if len(pages) != 3 {
+				t.Fatalf("Expected 3 pages, got %d", len(pages))
+			}
+
+			// walk backwards from the last page
+			for i := len(pages) - 1; i > 0; i-- {
+				page, err := qs.Paginate(pages[i].Previous, 3)
+				if err != nil {
+					t.Fatalf("Failed to paginate backwards: %v", err)
+				}
+
+				var want = strings.Join(ids(pages[i-1].Rows), ",")
+				if got := strings.Join(ids(page.Rows), ","); got != want {
+					t.Fatalf("Expected previous page %d to be %s, got %s", i, want, got)
+				}
+
+				if page.Next != pages[i-1].Next {
+					t.Fatalf("Expected previous page %d to have the same next cursor", i)
+				}
+
+				if page.HasPrevious() != (i > 1) {
+					t.Fatalf("Expected previous page %d HasPrevious() to be %v", i, i > 1)
+				}
+			}
+		})
+	}
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		var page, err = queries.GetQuerySet(&TestPaginated{}).OrderBy("Score").Paginate("", 3)
+		if err != nil {
+			t.Fatalf("Failed to paginate: %v", err)
+		}
+
+		_, err = queries.GetQuerySet(&TestPaginated{}).OrderBy("-Score").Paginate(page.Next, 3)
+		if !errors.Is(err, query_errors.ErrInvalidCursor) {
+			t.Fatalf("Expected ErrInvalidCursor, got %v", err)
+		}
+
+		_, err = queries.GetQuerySet(&TestPaginated{}).Paginate("not-a-cursor", 3)
+		if !errors.Is(err, query_errors.ErrInvalidCursor) {
+			t.Fatalf("Expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
+
+func TestQuerySetIterator(t *testing.T) {
+	var (
+		root1 = &Category{Name: "iteratorroot1"}
+		root2 = &Category{Name: "iteratorroot2"}
+	)
+
+	var categories = []*Category{root1, root2}
+	for i := 0; i < 5; i++ {
+		var parent = root1
+		if i%2 == 1 {
+			parent = root2
+		}
+		categories = append(categories, &Category{
+			Name:   fmt.Sprintf("iteratorchild%d", i),
+			Parent: parent,
+		})
+	}
+
+	for _, c := range categories {
+		if err := queries.CreateObject(c); err != nil {
+			t.Fatalf("Failed to create category: %v", err)
+		}
+	}
+
+	defer func() {
+		for i := len(categories) - 1; i >= 0; i-- {
+			if _, err := queries.GetQuerySet(&Category{}).Filter("ID", categories[i].ID).Delete(); err != nil {
+				t.Errorf("Failed to delete category: %v", err)
+			}
+		}
+	}()
+
+	var qs = queries.GetQuerySet(&Category{}).
+		Select("*", "Parent.*").
+		Filter("Name__startswith", "iterator").
+		OrderBy("ID")
+
+	for _, chunkSize := range []int{1, 2, 3, 100} {
+		t.Run(fmt.Sprintf("ChunkSize%d", chunkSize), func(t *testing.T) {
+			var got = make([]*Category, 0, len(categories))
+			for obj, err := range qs.Iterator(chunkSize) {
+				if err != nil {
+					t.Fatalf("Failed to iterate: %v", err)
+				}
+				got = append(got, obj)
+			}
+
+			if len(got) != len(categories) {
+				t.Fatalf("Expected %d categories, got %d", len(categories), len(got))
+			}
+
+			for i, obj := range got {
+				var expected = categories[i]
+				if obj.ID != expected.ID || obj.Name != expected.Name {
+					t.Fatalf("Expected category %d to be %q, got %q", i, expected.Name, obj.Name)
+				}
+
+				if expected.Parent == nil {
+					if obj.Parent != nil {
+						t.Fatalf("Expected category %q to have no parent, got %v", obj.Name, obj.Parent)
+					}
+					continue
+				}
+
+				if obj.Parent == nil || obj.Parent.ID != expected.Parent.ID || obj.Parent.Name != expected.Parent.Name {
+					t.Fatalf("Expected category %q to have parent %q, got %v", obj.Name, expected.Parent.Name, obj.Parent)
+				}
+			}
+		})
+	}
+
+	t.Run("Break", func(t *testing.T) {
+		var count int
+		for _, err := range qs.Iterator(2) {
+			if err != nil {
+				t.Fatalf("Failed to iterate: %v", err)
+			}
+			count++
+			if count == 3 {
+				break
+			}
+		}
+
+		if count != 3 {
+			t.Fatalf("Expected to stop after 3 categories, got %d", count)
+		}
+	})
+
+	t.Run("MultiValuedRelation", func(t *testing.T) {
+		var count int
+		for _, err := range queries.GetQuerySet(&User{}).Select("*", "ModelManyToManySet.*").Iterator(10) {
+			count++
+			if !errors.Is(err, query_errors.ErrDedupeRequired) {
+				t.Fatalf("Expected ErrDedupeRequired, got %v", err)
+			}
+		}
+
+		if count != 1 {
+			t.Fatalf("Expected a single error, got %d results", count)
+		}
+	})
+}
+
+func TestQuerySetExplain(t *testing.T) {
+	var qs = queries.GetQuerySet(&Todo{}).
+		Select("*", "User.*").
+		Filter("Done", false).
+		OrderBy("ID")
+
+	t.Run("Explain", func(t *testing.T) {
+		if db_tag == "mysql_local" {
+			t.Skip("the local MySQL server does not return a MySQL compatible query plan")
+		}
+
+		var output, err = qs.Explain()
+		if err != nil {
+			t.Fatalf("Failed to explain query: %v", err)
+		}
+
+		if output == "" {
+			t.Fatal("Expected a query plan, got empty output")
+		}
+
+		var sql = qs.LatestQuery().SQL()
+		switch db_tag {
+		case "sqlite":
+			if !strings.HasPrefix(sql, "EXPLAIN QUERY PLAN SELECT") {
+				t.Fatalf("Expected EXPLAIN QUERY PLAN, got %s", sql)
+			}
+			if !strings.HasPrefix(output, "QUERY PLAN\n") {
+				t.Fatalf("Expected output to start with QUERY PLAN, got %s", output)
+			}
+		default:
+			if !strings.HasPrefix(sql, "EXPLAIN SELECT") {
+				t.Fatalf("Expected EXPLAIN, got %s", sql)
+			}
+		}
+	})
+
+	t.Run("ExplainPlan", func(t *testing.T) {
+		if db_tag == "mysql_local" {
+			t.Skip("the local MySQL server does not support EXPLAIN FORMAT=JSON")
+		}
+
+		var root, err = qs.ExplainPlan()
+		if err != nil {
+			t.Fatalf("Failed to explain query: %v", err)
+		}
+
+		if root.Detail != "QUERY PLAN" || len(root.Children) == 0 {
+			t.Fatalf("Expected a QUERY PLAN root with children, got %s", root)
+		}
+
+		var nodes int
+		root.Walk(func(node *queries.PlanNode, depth int) bool {
+			nodes++
+			return true
+		})
+
+		if nodes < 2 {
+			t.Fatalf("Expected at least 2 nodes in the plan, got %d", nodes)
+		}
+
+		t.Logf("Query plan:\n%s", root)
+	})
+
+	t.Run("Analyze", func(t *testing.T) {
+		var _, err = qs.Explain(queries.ExplainAnalyze())
+		if db_tag == "sqlite" {
+			if !errors.Is(err, query_errors.ErrNotImplemented) {
+				t.Fatalf("Expected ErrNotImplemented, got %v", err)
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("Failed to explain query: %v", err)
+		}
+	})
+}
+
+type TestUpsert struct {
+	ID    int64
+	Email string
+	Name  string
+	Count int
+
+	// called before the object is created, used to simulate concurrent inserts
+	beforeCreate func() error
+}
+
+func (t *TestUpsert) BeforeCreate(qs *queries.GenericQuerySet) error {
+	if t.beforeCreate != nil {
+		return t.beforeCreate()
+	}
+	return nil
+}
+
+func (t *TestUpsert) FieldDefs() attrs.Definitions {
+	return attrs.Define(t,
+		attrs.Unbound("ID", &attrs.FieldConfig{
+			Primary: true,
+		}),
+		attrs.Unbound("Email", &attrs.FieldConfig{
+			Attributes: map[string]any{
+				attrs.AttrUniqueKey: true,
+			},
+		}),
+		attrs.Unbound("Name"),
+		attrs.Unbound("Count"),
+	)
+}
+
+func TestQuerySetBulkUpsert(t *testing.T) {
+	var tables = quest.Table(t, &TestUpsert{})
+	tables.Create()
+	defer tables.Drop()
+
+	var created, err = queries.GetQuerySet(&TestUpsert{}).BulkCreate([]*TestUpsert{
+		{Email: "upsert1@example.com", Name: "Upsert1", Count: 1},
+		{Email: "upsert2@example.com", Name: "Upsert2", Count: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create objects: %v", err)
+	}
+
+	var ids = make(map[string]int64)
+	for _, obj := range created {
+		ids[obj.Email] = obj.ID
+	}
+
+	var check = func(t *testing.T, expected map[string][2]any) {
+		t.Helper()
+
+		var rows, err = queries.GetQuerySet(&TestUpsert{}).OrderBy("ID").All()
+		if err != nil {
+			t.Fatalf("Failed to retrieve objects: %v", err)
+		}
+
+		if len(rows) != len(expected) {
+			t.Fatalf("Expected %d objects, got %d", len(expected), len(rows))
+		}
+
+		for _, row := range rows {
+			var exp, ok = expected[row.Object.Email]
+			if !ok {
+				t.Fatalf("Unexpected object %q", row.Object.Email)
+			}
+			if row.Object.Name != exp[0] || row.Object.Count != exp[1] {
+				t.Fatalf("Expected %q to be (%v, %v), got (%q, %d)", row.Object.Email, exp[0], exp[1], row.Object.Name, row.Object.Count)
+			}
+		}
+	}
+
+	t.Run("Update", func(t *testing.T) {
+		var objects, err = queries.GetQuerySet(&TestUpsert{}).BulkUpsert([]*TestUpsert{
+			{Email: "upsert1@example.com", Name: "Upsert1Updated", Count: 10},
+			{Email: "upsert3@example.com", Name: "Upsert3", Count: 3},
+		}, []string{"Email"}, []string{"Name", "Count"})
+		if err != nil {
+			t.Fatalf("Failed to upsert objects: %v", err)
+		}
+
+		if objects[0].ID != ids["upsert1@example.com"] {
+			t.Fatalf("Expected updated object to have ID %d, got %d", ids["upsert1@example.com"], objects[0].ID)
+		}
+
+		if objects[1].ID == 0 || objects[1].ID == ids["upsert1@example.com"] || objects[1].ID == ids["upsert2@example.com"] {
+			t.Fatalf("Expected inserted object to have a new ID, got %d", objects[1].ID)
+		}
+		ids["upsert3@example.com"] = objects[1].ID
+
+		check(t, map[string][2]any{
+			"upsert1@example.com": {"Upsert1Updated", 10},
+			"upsert2@example.com": {"Upsert2", 2},
+			"upsert3@example.com": {"Upsert3", 3},
+		})
+	})
+
+	t.Run("PartialUpdate", func(t *testing.T) {
+		var _, err = queries.GetQuerySet(&TestUpsert{}).BulkUpsert([]*TestUpsert{
+			{Email: "upsert2@example.com", Name: "Upsert2Updated", Count: 20},
+		}, []string{"Email"}, []string{"Count"})
+		if err != nil {
+			t.Fatalf("Failed to upsert objects: %v", err)
+		}
+
+		check(t, map[string][2]any{
+			"upsert1@example.com": {"Upsert1Updated", 10},
+			"upsert2@example.com": {"Upsert2", 20},
+			"upsert3@example.com": {"Upsert3", 3},
+		})
+	})
+
+	t.Run("NoUpdate", func(t *testing.T) {
+		var objects, err = queries.GetQuerySet(&TestUpsert{}).BulkUpsert([]*TestUpsert{
+			{Email: "upsert3@example.com", Name: "Upsert3Updated", Count: 30},
+		}, []string{"Email"}, nil)
+		if err != nil {
+			t.Fatalf("Failed to upsert objects: %v", err)
+		}
+
+		if objects[0].ID != ids["upsert3@example.com"] {
+			t.Fatalf("Expected existing object to have ID %d, got %d", ids["upsert3@example.com"], objects[0].ID)
+		}
+
+		check(t, map[string][2]any{
+			"upsert1@example.com": {"Upsert1Updated", 10},
+			"upsert2@example.com": {"Upsert2", 20},
+			"upsert3@example.com": {"Upsert3", 3},
+		})
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		var _, err = queries.GetQuerySet(&TestUpsert{}).BulkUpsert([]*TestUpsert{
+			{Email: "upsert4@example.com"},
+		}, []string{"Unknown"}, nil)
+		if !errors.Is(err, query_errors.ErrFieldNotFound) {
+			t.Fatalf("Expected ErrFieldNotFound, got %v", err)
+		}
+	})
+}
+
+func TestQuerySetUpdateOrCreate(t *testing.T) {
+	var tables = quest.Table(t, &TestUpsert{})
+	tables.Create()
+	defer tables.Drop()
+
+	var obj, created, err = queries.GetQuerySet(&TestUpsert{}).UpdateOrCreate(
+		map[string]any{"Email": "update-or-create@example.com"},
+		map[string]any{"Name": "Created", "Count": 1},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create object: %v", err)
+	}
+
+	if !created || obj.ID == 0 || obj.Email != "update-or-create@example.com" || obj.Name != "Created" || obj.Count != 1 {
+		t.Fatalf("Expected object to be created, got (%v) %+v", created, obj)
+	}
+
+	var id = obj.ID
+	obj, created, err = queries.GetQuerySet(&TestUpsert{}).UpdateOrCreate(
+		map[string]any{"Email": "update-or-create@example.com"},
+		map[string]any{"Count": 2},
+	)
+	if err != nil {
+		t.Fatalf("Failed to update object: %v", err)
+	}
+
+	if created || obj.ID != id || obj.Name != "Created" || obj.Count != 2 {
+		t.Fatalf("Expected object %d to be updated, got (%v) %+v", id, created, obj)
+	}
+
+	row, err := queries.GetQuerySet(&TestUpsert{}).Filter("ID", id).Get()
+	if err != nil {
+		t.Fatalf("Failed to retrieve object: %v", err)
+	}
+
+	if row.Object.Name != "Created" || row.Object.Count != 2 {
+		t.Fatalf("Expected object to be updated in the database, got %+v", row.Object)
+	}
+
+	count, err := queries.GetQuerySet(&TestUpsert{}).Count()
+	if err != nil {
+		t.Fatalf("Failed to count objects: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected 1 object, got %d", count)
+	}
+}
+
+func TestQuerySetGetOrCreateConcurrent(t *testing.T) {
+	var tables = quest.Table(t, &TestUpsert{})
+	tables.Create()
+	defer tables.Drop()
+
+	// without an implicit transaction the concurrent
+	// insert in beforeCreate does not have to wait for a lock.
+	var implicit = queries.QUERYSET_CREATE_IMPLICIT_TRANSACTION
+	queries.QUERYSET_CREATE_IMPLICIT_TRANSACTION = false
+	defer func() {
+		queries.QUERYSET_CREATE_IMPLICIT_TRANSACTION = implicit
+	}()
+
+	var concurrent *TestUpsert
+	var insertConcurrently = func(email string) func() error {
+		return func() error {
+			var err error
+			concurrent, err = queries.GetQuerySet(&TestUpsert{}).Create(&TestUpsert{
+				Email: email,
+				Name:  "Concurrent",
+				Count: 1,
+			})
+			return err
+		}
+	}
+
+	t.Run("IsUniqueViolation", func(t *testing.T) {
+		var _, err = queries.GetQuerySet(&TestUpsert{}).BulkCreate([]*TestUpsert{
+			{Email: "duplicate@example.com"},
+			{Email: "duplicate@example.com"},
+		})
+		if !drivers.IsUniqueViolation(err) {
+			t.Fatalf("Expected a unique violation, got %v", err)
+		}
+
+		if drivers.IsUniqueViolation(errors.New("some error")) {
+			t.Fatal("Expected a plain error not to be a unique violation")
+		}
+	})
+
+	t.Run("GetOrCreate", func(t *testing.T) {
+		var obj, created, err = queries.GetQuerySet(&TestUpsert{}).
+			Filter("Email", "get-or-create@example.com").
+			GetOrCreate(&TestUpsert{
+				Email:        "get-or-create@example.com",
+				Name:         "GetOrCreate",
+				beforeCreate: insertConcurrently("get-or-create@example.com"),
+			})
+		if err != nil {
+			t.Fatalf("Expected the concurrently created object, got error: %v", err)
+		}
+
+		if created || concurrent == nil || obj.ID != concurrent.ID || obj.Name != "Concurrent" {
+			t.Fatalf("Expected the concurrently created object, got (%v) %+v", created, obj)
+		}
+	})
+
+	// a failed insert does not abort the enclosing transaction,
+	// postgres runs the insert in a savepoint which is rolled back.
+	t.Run("InTransaction", func(t *testing.T) {
+		var errCreate = errors.New("failed to create")
+		var emails = func(ctx context.Context) []string {
+			var rows, err = queries.GetQuerySetWithContext(ctx, &TestUpsert{}).
+				Filter("Email__startswith", "savepoint").
+				OrderBy("ID").
+				All()
+			if err != nil {
+				t.Fatalf("Failed to get objects: %v", err)
+			}
+
+			var list = make([]string, 0, len(rows))
+			for _, row := range rows {
+				list = append(list, row.Object.Email)
+			}
+			return list
+		}
+
+		var err = queries.RunInTransaction(context.Background(), func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestUpsert]) (bool, error) {
+			if _, err := NewQuerySet(&TestUpsert{}).Create(&TestUpsert{Email: "savepoint-outer@example.com"}); err != nil {
+				return false, err
+			}
+
+			// the object created before the insert fails is rolled back with the savepoint on postgres
+			var insertInSavepoint = func() error {
+				if _, err := queries.GetQuerySetWithContext(ctx, &TestUpsert{}).Create(&TestUpsert{Email: "savepoint-inner@example.com"}); err != nil {
+					return err
+				}
+				return errCreate
+			}
+
+			var _, _, err = NewQuerySet(&TestUpsert{}).
+				Filter("Email", "savepoint-get@example.com").
+				GetOrCreate(&TestUpsert{Email: "savepoint-get@example.com", beforeCreate: insertInSavepoint})
+			if !errors.Is(err, errCreate) {
+				return false, fmt.Errorf("expected GetOrCreate to fail, got %v", err)
+			}
+
+			_, _, err = NewQuerySet(&TestUpsert{}).UpdateOrCreate(
+				map[string]any{"Email": "savepoint-update@example.com"},
+				map[string]any{"Name": "Savepoint"},
+			)
+			if err != nil {
+				return false, err
+			}
+
+			var expected = "savepoint-outer@example.com,savepoint-inner@example.com,savepoint-update@example.com"
+			if db_tag == "postgres" {
+				expected = "savepoint-outer@example.com,savepoint-update@example.com"
+			}
+
+			if got := strings.Join(emails(ctx), ","); got != expected {
+				return false, fmt.Errorf("expected %s, got %s", expected, got)
+			}
+
+			return true, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+	})
+}
+
+func TestQuerySetForUpdateOptions(t *testing.T) {
+	var tests = []struct {
+		name     string
+		options  queries.ForUpdateOptions
+		expected map[string]string
+	}{
+		{
+			name:    "Default",
+			options: queries.ForUpdateOptions{},
+			expected: map[string]string{
+				"default": " FOR UPDATE",
+			},
+		},
+		{
+			name:    "SkipLocked",
+			options: queries.ForUpdateOptions{SkipLocked: true},
+			expected: map[string]string{
+				"default": " FOR UPDATE SKIP LOCKED",
+			},
+		},
+		{
+			name:    "ShareNoWait",
+			options: queries.ForUpdateOptions{Share: true, NoWait: true},
+			expected: map[string]string{
+				"default": " FOR SHARE NOWAIT",
+				"mariadb": " LOCK IN SHARE MODE NOWAIT",
+			},
+		},
+		{
+			name:    "Of",
+			options: queries.ForUpdateOptions{Of: []string{"self", "User"}},
+			expected: map[string]string{
+				"default": " FOR UPDATE OF ",
+				"mariadb": "",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if db_tag == "mysql_local" && (test.options.Share || len(test.options.Of) > 0) {
+				t.Skip("the local MySQL server does not support FOR SHARE and FOR UPDATE OF")
+			}
+
+			var qs = queries.GetQuerySet(&Todo{}).
+				Select("*", "User.*").
+				ForUpdate(test.options).
+				OrderBy("ID")
+
+			var _, err = qs.All()
+			var sql = qs.LatestQuery().SQL()
+
+			var expected, ok = test.expected[db_tag]
+			if !ok {
+				expected = test.expected["default"]
+			}
+
+			switch {
+			case db_tag == "sqlite":
+				if err != nil {
+					t.Fatalf("Failed to execute query: %v", err)
+				}
+				if strings.Contains(sql, " FOR ") {
+					t.Fatalf("Expected no locking clause for SQLite, got %s", sql)
+				}
+			case expected == "":
+				if !errors.Is(err, query_errors.ErrNotImplemented) {
+					t.Fatalf("Expected ErrNotImplemented, got %v", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Failed to execute query: %v", err)
+				}
+				if !strings.Contains(sql, expected) {
+					t.Fatalf("Expected %q in %s", expected, sql)
+				}
+			}
+		})
+	}
+
+	t.Run("NoWaitSkipLocked", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Expected a panic when combining NoWait and SkipLocked")
+			}
+		}()
+
+		queries.GetQuerySet(&Todo{}).ForUpdate(queries.ForUpdateOptions{
+			NoWait:     true,
+			SkipLocked: true,
+		})
+	})
+}
+
+func TestQuerySetDeferOnly(t *testing.T) {
+	var tests = []struct {
+		name       string
+		qs         func() *queries.QuerySet[*Todo]
+		selected   []string
+		unselected []string
+	}{
+		{
+			name: "Defer",
+			qs: func() *queries.QuerySet[*Todo] {
+				return queries.GetQuerySet(&Todo{}).Defer("Description")
+			},
+			selected:   []string{"id", "title", "done", "user_id"},
+			unselected: []string{"description"},
+		},
+		{
+			name: "DeferBeforeSelect",
+			qs: func() *queries.QuerySet[*Todo] {
+				return queries.GetQuerySet(&Todo{}).Defer("Description", "Done").Select("*", "User.*")
+			},
+			selected:   []string{"id", "title", "user_id", "name"},
+			unselected: []string{"description", "done"},
+		},
+		{
+			name: "Only",
+			qs: func() *queries.QuerySet[*Todo] {
+				return queries.GetQuerySet(&Todo{}).Only("Title", "User.*")
+			},
+			selected:   []string{"id", "title", "name"},
+			unselected: []string{"description", "done"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var qs = test.qs()
+			if _, err := qs.All(); err != nil {
+				t.Fatalf("Failed to execute query: %v", err)
+			}
+
+			var sql = qs.LatestQuery().SQL()
+			var selectClause, _, _ = strings.Cut(sql, " FROM ")
+			for _, column := range test.selected {
+				if !strings.Contains(selectClause, column) {
+					t.Errorf("Expected %q to be selected in %s", column, sql)
+				}
+			}
+
+			for _, column := range test.unselected {
+				if strings.Contains(selectClause, column) {
+					t.Errorf("Expected %q not to be selected in %s", column, sql)
+				}
+			}
+		})
+	}
+
+	t.Run("DeferPrimaryKey", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Expected a panic when deferring the primary key")
+			}
+		}()
+
+		queries.GetQuerySet(&Todo{}).Defer("ID")
+	})
+}
+
+func TestQuerySetDistinctOn(t *testing.T) {
+	var authors = []*Author{
+		{Name: "TestQuerySetDistinctOn1"},
+		{Name: "TestQuerySetDistinctOn2"},
+		{Name: "TestQuerySetDistinctOn3"},
+	}
+
+	for _, author := range authors {
+		if err := queries.CreateObject(author); err != nil {
+			t.Fatalf("Failed to create author: %v", err)
+		}
+	}
+
+	var books = []*Book{
+		{Title: "TestQuerySetDistinctOn1a", Author: authors[0]},
+		{Title: "TestQuerySetDistinctOn1b", Author: authors[0]},
+		{Title: "TestQuerySetDistinctOn2a", Author: authors[1]},
+		{Title: "TestQuerySetDistinctOn2b", Author: authors[1]},
+		{Title: "TestQuerySetDistinctOn2c", Author: authors[1]},
+	}
+
+	for _, book := range books {
+		if err := queries.CreateObject(book); err != nil {
+			t.Fatalf("Failed to create book: %v", err)
+		}
+	}
+
+	var authorIDs = []any{authors[0].ID, authors[1].ID, authors[2].ID}
+	defer func() {
+		if _, err := queries.Objects[attrs.Definer](&Book{}).Filter("Author__in", authorIDs...).Delete(); err != nil {
+			t.Errorf("Failed to delete books: %v", err)
+		}
+		if _, err := queries.Objects[attrs.Definer](&Author{}).Filter("ID__in", authorIDs...).Delete(); err != nil {
+			t.Errorf("Failed to delete authors: %v", err)
+		}
+	}()
+
+	var tests = []struct {
+		name     string
+		qs       func() *queries.QuerySet[*Book]
+		expected []string
+	}{
+		{
+			name: "Latest",
+			qs: func() *queries.QuerySet[*Book] {
+				return queries.GetQuerySet(&Book{}).
+					Filter("Author__in", authorIDs...).
+					DistinctOn("Author").
+					OrderBy("Author", "-ID")
+			},
+			expected: []string{books[1].Title, books[4].Title},
+		},
+		{
+			name: "First",
+			qs: func() *queries.QuerySet[*Book] {
+				return queries.GetQuerySet(&Book{}).
+					Filter("Author__in", authorIDs...).
+					DistinctOn("Author").
+					OrderBy("Author", "ID")
+			},
+			expected: []string{books[0].Title, books[2].Title},
+		},
+		{
+			name: "WithRelation",
+			qs: func() *queries.QuerySet[*Book] {
+				return queries.GetQuerySet(&Book{}).
+					Select("*", "Author.*").
+					Filter("Author__in", authorIDs...).
+					Filter("Title__endswith", "a").
+					DistinctOn("Author").
+					OrderBy("Author", "-Title")
+			},
+			expected: []string{books[0].Title, books[2].Title},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var qs = test.qs()
+			var rows, err = qs.All()
+			if err != nil {
+				t.Fatalf("Failed to execute query: %v", err)
+			}
+
+			var sql = qs.LatestQuery().SQL()
+			if db_tag == "postgres" && !strings.Contains(sql, "DISTINCT ON (") {
+				t.Errorf("Expected DISTINCT ON in %s", sql)
+			}
+			if db_tag != "postgres" && !strings.Contains(sql, "ROW_NUMBER() OVER (PARTITION BY ") {
+				t.Errorf("Expected ROW_NUMBER() in %s", sql)
+			}
+
+			if len(rows) != len(test.expected) {
+				t.Fatalf("Expected %d rows, got %d: %s", len(test.expected), len(rows), sql)
+			}
+
+			for i, row := range rows {
+				if row.Object.Title != test.expected[i] {
+					t.Errorf("Expected row %d to be %q, got %q", i, test.expected[i], row.Object.Title)
+				}
+			}
+
+			// the count is the number of distinct groups
+			var countQs = test.qs()
+			count, err := countQs.Count()
+			if err != nil {
+				t.Fatalf("Failed to count rows: %v", err)
+			}
+
+			if count != int64(len(test.expected)) {
+				t.Errorf("Expected a count of %d, got %d: %s", len(test.expected), count, countQs.LatestQuery().SQL())
+			}
+		})
+	}
+
+	t.Run("OrderByMismatch", func(t *testing.T) {
+		var _, err = queries.GetQuerySet(&Book{}).
+			DistinctOn("Author").
+			OrderBy("-ID").
+			All()
+		if err == nil {
+			t.Fatal("Expected an error when the ordering does not start with the DISTINCT ON fields")
+		}
+	})
+}
+
+func TestQuerySetInBulk(t *testing.T) {
+	var tables = quest.Table(t, &TestUpsert{})
+	tables.Create()
+	defer tables.Drop()
+
+	var created, err = queries.GetQuerySet(&TestUpsert{}).BulkCreate([]*TestUpsert{
+		{Email: "inbulk1@example.com", Name: "InBulk1", Count: 1},
+		{Email: "inbulk2@example.com", Name: "InBulk2", Count: 2},
+		{Email: "inbulk3@example.com", Name: "InBulk3", Count: 3},
+		{Email: "inbulk4@example.com", Name: "InBulk4", Count: 4},
+		{Email: "inbulk5@example.com", Name: "InBulk5", Count: 5},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create objects: %v", err)
+	}
+
+	t.Run("PrimaryKey", func(t *testing.T) {
+		var objects, err = queries.GetQuerySet(&TestUpsert{}).InBulk([]any{
+			created[0].ID, created[2].ID, int64(-1),
+		})
+		if err != nil {
+			t.Fatalf("Failed to retrieve objects: %v", err)
+		}
+
+		if len(objects) != 2 {
+			t.Fatalf("Expected 2 objects, got %d", len(objects))
+		}
+
+		for _, obj := range []*TestUpsert{created[0], created[2]} {
+			var found, ok = objects[obj.ID]
+			if !ok || found.Email != obj.Email {
+				t.Errorf("Expected object %d to be %q, got %v", obj.ID, obj.Email, found)
+			}
+		}
+	})
+
+	t.Run("UniqueField", func(t *testing.T) {
+		var defaultParams = queries.MAX_IN_BULK_PARAMS
+		queries.MAX_IN_BULK_PARAMS = 2
+		defer func() {
+			queries.MAX_IN_BULK_PARAMS = defaultParams
+		}()
+
+		var objects, err = queries.GetQuerySet(&TestUpsert{}).
+			Filter("Count__gt", 1).
+			InBulk([]any{
+				"inbulk1@example.com",
+				"inbulk2@example.com",
+				"inbulk4@example.com",
+				"inbulk5@example.com",
+				"inbulk6@example.com",
+			}, "Email")
+		if err != nil {
+			t.Fatalf("Failed to retrieve objects: %v", err)
+		}
+
+		if len(objects) != 3 {
+			t.Fatalf("Expected 3 objects, got %d", len(objects))
+		}
+
+		for _, email := range []string{"inbulk2@example.com", "inbulk4@example.com", "inbulk5@example.com"} {
+			if obj, ok := objects[email]; !ok || obj.Email != email {
+				t.Errorf("Expected object for %q, got %v", email, obj)
+			}
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		var objects, err = queries.GetQuerySet(&TestUpsert{}).InBulk(nil)
+		if err != nil {
+			t.Fatalf("Failed to retrieve objects: %v", err)
+		}
+
+		if len(objects) != 0 {
+			t.Fatalf("Expected no objects, got %d", len(objects))
+		}
+	})
+
+	t.Run("NotUnique", func(t *testing.T) {
+		var _, err = queries.GetQuerySet(&TestUpsert{}).InBulk([]any{"InBulk1"}, "Name")
+		if !errors.Is(err, query_errors.ErrNoUniqueKey) {
+			t.Fatalf("Expected ErrNoUniqueKey, got %v", err)
+		}
+	})
+}
+
+func TestQuerySetCache(t *testing.T) {
+	var tables = quest.Table(t, &TestUpsert{})
+	tables.Create()
+	defer tables.Drop()
+
+	var created, err = queries.GetQuerySet(&TestUpsert{}).BulkCreate([]*TestUpsert{
+		{Email: "cache1@example.com", Name: "Cache1", Count: 1},
+		{Email: "cache2@example.com", Name: "Cache2", Count: 2},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create objects: %v", err)
+	}
+
+	var (
+		backend   = queries.NewLRUCache(8)
+		tableName = (&TestUpsert{}).FieldDefs().TableName()
+		ctx       = context.Background()
+	)
+
+	var count = func(t *testing.T, expected int64) {
+		t.Helper()
+		var cnt, err = queries.GetQuerySet(&TestUpsert{}).
+			Filter("Count__gt", 0).
+			Cache(backend, 0).
+			Count()
+		if err != nil {
+			t.Fatalf("Failed to count objects: %v", err)
+		}
+		if cnt != expected {
+			t.Fatalf("Expected %d objects, got %d", expected, cnt)
+		}
+	}
+
+	// rows inserted with raw SQL do not invalidate the cache
+	var insertRaw = func(t *testing.T, email string) {
+		t.Helper()
+		var _, err = queries.GetQuerySet(&TestUpsert{}).DB().ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (email, name, count) VALUES ('%s', 'Raw', 1)", tableName, email,
+		))
+		if err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+
+	t.Run("Cached", func(t *testing.T) {
+		count(t, 2)
+		insertRaw(t, "raw1@example.com")
+		count(t, 2)
+
+		var rows, err = queries.GetQuerySet(&TestUpsert{}).
+			Cache(backend, 0).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to retrieve objects: %v", err)
+		}
+
+		insertRaw(t, "raw2@example.com")
+
+		cachedRows, err := queries.GetQuerySet(&TestUpsert{}).
+			Cache(backend, 0).
+			OrderBy("ID").
+			All()
+		if err != nil {
+			t.Fatalf("Failed to retrieve objects: %v", err)
+		}
+
+		if len(rows) != 3 || len(cachedRows) != 3 {
+			t.Fatalf("Expected 3 cached rows, got %d and %d", len(rows), len(cachedRows))
+		}
+	})
+
+	t.Run("InvalidateCache", func(t *testing.T) {
+		queries.InvalidateCache(tableName)
+		count(t, 4)
+	})
+
+	t.Run("SignalPostModelSave", func(t *testing.T) {
+		insertRaw(t, "raw3@example.com")
+		count(t, 4)
+
+		var err = queries.SignalPostModelSave.Send(queries.SignalSave{
+			Instance: created[0],
+		})
+		if err != nil {
+			t.Fatalf("Failed to send signal: %v", err)
+		}
+
+		count(t, 5)
+	})
+
+	t.Run("SignalPostModelDelete", func(t *testing.T) {
+		insertRaw(t, "raw4@example.com")
+		count(t, 5)
+
+		if err := queries.SignalPostModelDelete.Send(created[0]); err != nil {
+			t.Fatalf("Failed to send signal: %v", err)
+		}
+
+		count(t, 6)
+	})
+
+	t.Run("QuerySetWrite", func(t *testing.T) {
+		var _, err = queries.GetQuerySet(&TestUpsert{}).
+			Filter("Email", "raw1@example.com").
+			Delete()
+		if err != nil {
+			t.Fatalf("Failed to delete object: %v", err)
+		}
+
+		count(t, 5)
+
+		_, err = queries.GetQuerySet(&TestUpsert{}).BulkCreate([]*TestUpsert{
+			{Email: "cache3@example.com", Name: "Cache3", Count: 3},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create object: %v", err)
+		}
+
+		count(t, 6)
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		var qs = queries.GetQuerySet(&TestUpsert{}).Cache(backend, time.Millisecond*50)
+		var exists, err = qs.Filter("Email", "raw5@example.com").Exists()
+		if err != nil || exists {
+			t.Fatalf("Expected no object, got %v (%v)", exists, err)
+		}
+
+		insertRaw(t, "raw5@example.com")
+
+		exists, err = qs.Filter("Email", "raw5@example.com").Exists()
+		if err != nil || exists {
+			t.Fatalf("Expected the cached result, got %v (%v)", exists, err)
+		}
+
+		time.Sleep(time.Millisecond * 100)
+
+		exists, err = qs.Filter("Email", "raw5@example.com").Exists()
+		if err != nil || !exists {
+			t.Fatalf("Expected the result to expire, got %v (%v)", exists, err)
+		}
+	})
+
+	t.Run("LRU", func(t *testing.T) {
+		var backend = queries.NewLRUCache(2)
+		for _, email := range []string{"cache1@example.com", "cache2@example.com", "cache3@example.com"} {
+			var _, err = queries.GetQuerySet(&TestUpsert{}).
+				Cache(backend, 0).
+				Filter("Email", email).
+				Get()
+			if err != nil {
+				t.Fatalf("Failed to retrieve object: %v", err)
+			}
+		}
+
+		if backend.Len() != 2 {
+			t.Fatalf("Expected 2 cached results, got %d", backend.Len())
+		}
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		var backend = queries.NewLRUCache(8)
+		var err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestUpsert]) (commit bool, err error) {
+			var _, err2 = NewQuerySet(&TestUpsert{}).Cache(backend, 0).Count()
+			return true, err2
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if backend.Len() != 0 {
+			t.Fatalf("Expected no cached results inside of a transaction, got %d", backend.Len())
+		}
+	})
+
+	t.Run("InvalidateOnCommit", func(t *testing.T) {
+		var backend = queries.NewLRUCache(8)
+		var _, err = queries.GetQuerySet(&TestUpsert{}).Cache(backend, 0).Count()
+		if err != nil {
+			t.Fatalf("Failed to count objects: %v", err)
+		}
+
+		err = queries.RunInTransaction(ctx, func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*TestUpsert]) (commit bool, err error) {
+			if _, err = NewQuerySet(&TestUpsert{}).Filter("Email", "raw2@example.com").Delete(); err != nil {
+				return false, err
+			}
+
+			if err = queries.SignalPostModelSave.Send(queries.SignalSave{
+				Instance: created[1],
+				Using:    NewQuerySet(&TestUpsert{}).Compiler(),
+			}); err != nil {
+				return false, err
+			}
+
+			// other connections still read the rows from before the transaction
+			if backend.Len() != 1 {
+				t.Errorf("Expected the cache to be invalidated after the commit, got %d cached results", backend.Len())
+			}
+			return true, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to run transaction: %v", err)
+		}
+
+		if backend.Len() != 0 {
+			t.Fatalf("Expected the cache to be invalidated after the commit, got %d cached results", backend.Len())
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package queries_test
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -1469,6 +1470,195 @@ var manyToManyTests = []ManyToManyTest{
 			return 0, 0, 0, 0, 0
 		},
 	},
+	{
+		Name: "TestPrefetch_ReverseForeignKey",
+		Test: func(t *testing.T, profiles []*Profile, users []*User, m2m_sources []*ModelManyToMany, m2m_targets []*ModelManyToMany_Target, m2m_throughs []*ModelManyToMany_Through) (int, int, int, int, int) {
+			var rows, err = queries.Objects(&User{}).
+				Select("ID", "Name").
+				Filter("ID__in", users[0].ID, users[1].ID).
+				OrderBy("ID").
+				Prefetch("ModelManyToManySet").
+				All()
+			if err != nil {
+				t.Fatalf("Failed to get objects: %v", err)
+			}
+
+			if len(rows) != 2 {
+				t.Fatalf("Expected 2 rows, got %d", len(rows))
+			}
+
+			var expected = [][]*ModelManyToMany{
+				{m2m_sources[0], m2m_sources[1]},
+				{m2m_sources[2]},
+			}
+
+			for i, row := range rows {
+				var set = row.Object.ModelManyToManySet.AsList()
+				if len(set) != len(expected[i]) {
+					t.Fatalf("Expected %d items in ModelManyToManySet for user %d, got %d", len(expected[i]), i, len(set))
+				}
+
+				for j, obj := range set {
+					var m2m = obj.(*ModelManyToMany)
+					if m2m.ID != expected[i][j].ID {
+						t.Fatalf("Expected ModelManyToManySet[%d].ID to be %d, got %d", j, expected[i][j].ID, m2m.ID)
+					}
+
+					if m2m.Title != expected[i][j].Title {
+						t.Fatalf("Expected ModelManyToManySet[%d].Title to be %q, got %q", j, expected[i][j].Title, m2m.Title)
+					}
+				}
+			}
+			return 0, 0, 0, 0, 0
+		},
+	},
+	{
+		Name: "TestPrefetch_Iterator",
+		Test: func(t *testing.T, profiles []*Profile, users []*User, m2m_sources []*ModelManyToMany, m2m_targets []*ModelManyToMany_Target, m2m_throughs []*ModelManyToMany_Through) (int, int, int, int, int) {
+			var expected = [][]*ModelManyToMany{
+				{m2m_sources[0], m2m_sources[1]},
+				{m2m_sources[2]},
+			}
+
+			// the transaction runs on a single connection, the prefetches
+			// and the loop body cannot query while a cursor is open.
+			var err = queries.RunInTransaction(context.Background(), func(ctx context.Context, NewQuerySet queries.ObjectsFunc[*User]) (bool, error) {
+				var i int
+				for user, err := range NewQuerySet(&User{}).
+					Select("ID", "Name").
+					Filter("ID__in", users[0].ID, users[1].ID).
+					OrderBy("ID").
+					Prefetch("ModelManyToManySet").
+					Iterator(1) {
+					if err != nil {
+						return false, err
+					}
+
+					var count, err = NewQuerySet(&User{}).Filter("ID", user.ID).Count()
+					if err != nil {
+						return false, err
+					}
+
+					if count != 1 {
+						t.Fatalf("Expected to count 1 user for %d, got %d", user.ID, count)
+					}
+
+					var set = user.ModelManyToManySet.AsList()
+					if len(set) != len(expected[i]) {
+						t.Fatalf("Expected %d items in ModelManyToManySet for user %d, got %d", len(expected[i]), i, len(set))
+					}
+
+					for j, obj := range set {
+						if obj.(*ModelManyToMany).ID != expected[i][j].ID {
+							t.Fatalf("Expected ModelManyToManySet[%d].ID to be %d, got %d", j, expected[i][j].ID, obj.(*ModelManyToMany).ID)
+						}
+					}
+					i++
+				}
+
+				if i != len(expected) {
+					t.Fatalf("Expected %d users, got %d", len(expected), i)
+				}
+				return false, nil
+			})
+			if err != nil {
+				t.Fatalf("Failed to iterate: %v", err)
+			}
+			return 0, 0, 0, 0, 0
+		},
+	},
+	{
+		Name: "TestPrefetch_ManyToMany",
+		Test: func(t *testing.T, profiles []*Profile, users []*User, m2m_sources []*ModelManyToMany, m2m_targets []*ModelManyToMany_Target, m2m_throughs []*ModelManyToMany_Through) (int, int, int, int, int) {
+			var rows, err = queries.GetQuerySet(&ModelManyToMany{}).
+				Select("*").
+				Filter("ID__in", m2m_sources[0].ID, m2m_sources[1].ID, m2m_sources[2].ID).
+				OrderBy("ID").
+				Prefetch("Target").
+				All()
+			if err != nil {
+				t.Fatalf("Failed to get objects: %v", err)
+			}
+
+			if len(rows) != 3 {
+				t.Fatalf("Expected 3 rows, got %d", len(rows))
+			}
+
+			var expected = [][]*ModelManyToMany_Target{
+				{m2m_targets[0], m2m_targets[1], m2m_targets[2]},
+				{m2m_targets[1], m2m_targets[2], m2m_targets[3]},
+				{m2m_targets[1], m2m_targets[2], m2m_targets[3]},
+			}
+
+			for i, row := range rows {
+				var set = row.Object.Target.AsList()
+				if len(set) != len(expected[i]) {
+					t.Fatalf("Expected %d items in Target for source %d, got %d", len(expected[i]), i, len(set))
+				}
+
+				for j, rel := range set {
+					if rel.Object.ID != expected[i][j].ID {
+						t.Fatalf("Expected Target[%d].ID to be %d, got %d", j, expected[i][j].ID, rel.Object.ID)
+					}
+
+					if rel.ThroughObject == nil {
+						t.Fatalf("Expected Target[%d] to have a through object", j)
+					}
+
+					if rel.ThroughObject.SourceModel.ID != row.Object.ID {
+						t.Fatalf("Expected Target[%d].Through.SourceModel.ID to be %d, got %d", j, row.Object.ID, rel.ThroughObject.SourceModel.ID)
+					}
+				}
+			}
+			return 0, 0, 0, 0, 0
+		},
+	},
+	{
+		Name: "TestPrefetch_Nested_QuerySet",
+		Test: func(t *testing.T, profiles []*Profile, users []*User, m2m_sources []*ModelManyToMany, m2m_targets []*ModelManyToMany_Target, m2m_throughs []*ModelManyToMany_Through) (int, int, int, int, int) {
+			var rows, err = queries.Objects(&User{}).
+				Select("ID", "Name").
+				Filter("ID", users[0].ID).
+				Prefetch(queries.Prefetch{
+					Path: "ModelManyToManySet.Target",
+					QuerySet: queries.GetQuerySet[attrs.Definer](&ModelManyToMany_Target{}).
+						Filter("Age", 25).
+						OrderBy("-ID"),
+				}).
+				All()
+			if err != nil {
+				t.Fatalf("Failed to get objects: %v", err)
+			}
+
+			if len(rows) != 1 {
+				t.Fatalf("Expected 1 row, got %d", len(rows))
+			}
+
+			var set = rows[0].Object.ModelManyToManySet.AsList()
+			if len(set) != 2 {
+				t.Fatalf("Expected 2 items in ModelManyToManySet, got %d", len(set))
+			}
+
+			var expected = [][]*ModelManyToMany_Target{
+				{m2m_targets[2], m2m_targets[1], m2m_targets[0]},
+				{m2m_targets[2], m2m_targets[1]},
+			}
+
+			for i, obj := range set {
+				var targets = obj.(*ModelManyToMany).Target.AsList()
+				if len(targets) != len(expected[i]) {
+					t.Fatalf("Expected %d items in ModelManyToManySet[%d].Target, got %d", len(expected[i]), i, len(targets))
+				}
+
+				for j, rel := range targets {
+					if rel.Object.ID != expected[i][j].ID {
+						t.Fatalf("Expected ModelManyToManySet[%d].Target[%d].ID to be %d, got %d", i, j, expected[i][j].ID, rel.Object.ID)
+					}
+				}
+			}
+			return 0, 0, 0, 0, 0
+		},
+	},
 }
 
 func TestManyToMany(t *testing.T) {